@@ -0,0 +1,171 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/flight"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/run"
+	"robpike.io/ivy/value"
+)
+
+// EvalAction is the type of the Flight action that evaluates the ivy
+// program in its body. The result of the action is the program's output.
+const EvalAction = "eval"
+
+// FlightServer is an Arrow Flight service backed by a single ivy
+// execution context:
+//
+//   - DoPut loads the uploaded table into the context, making each
+//     numeric column a global variable named after the column.
+//   - DoAction with type "eval" evaluates the ivy program in the body.
+//   - DoGet streams the global variable named by the ticket as record
+//     batches of a single column with the variable's name.
+//
+// Calls are serialized, so a FlightServer may be used by multiple clients.
+type FlightServer struct {
+	flight.BaseFlightServer
+
+	mu      sync.Mutex
+	conf    *config.Config
+	context *exec.Context
+	mem     memory.Allocator
+}
+
+// NewFlightServer returns a FlightServer evaluating in a new context
+// with the given configuration.
+// Special commands such as )get and )save are disabled, since they
+// would give clients access to the server's files.
+func NewFlightServer(conf *config.Config) *FlightServer {
+	conf.SetSpecialCommands(false)
+	return &FlightServer{
+		conf:    conf,
+		context: exec.NewContext(conf).(*exec.Context),
+		mem:     memory.DefaultAllocator,
+	}
+}
+
+// Context returns the execution context used by the server.
+func (s *FlightServer) Context() *exec.Context {
+	return s.context
+}
+
+// DoPut implements flight.FlightServer.
+func (s *FlightServer) DoPut(stream flight.FlightService_DoPutServer) error {
+	rdr, err := flight.NewRecordReader(stream, ipc.WithAllocator(s.mem))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "reading table: %v", err)
+	}
+	defer rdr.Release()
+	var recs []arrow.Record
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	for rdr.Next() {
+		rec := rdr.Record()
+		rec.Retain()
+		recs = append(recs, rec)
+	}
+	if err := rdr.Err(); err != nil && err != io.EOF {
+		return status.Errorf(codes.InvalidArgument, "reading table: %v", err)
+	}
+	table := array.NewTableFromRecords(rdr.Schema(), recs)
+	defer table.Release()
+	s.mu.Lock()
+	err = s.context.LoadGlobalsFromTable(table, s.conf, nil)
+	s.mu.Unlock()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "loading table: %v", err)
+	}
+	return stream.Send(&flight.PutResult{})
+}
+
+// DoAction implements flight.FlightServer.
+func (s *FlightServer) DoAction(action *flight.Action, stream flight.FlightService_DoActionServer) error {
+	if action.Type != EvalAction {
+		return status.Errorf(codes.InvalidArgument, "unknown action %q", action.Type)
+	}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	s.mu.Lock()
+	run.Ivy(s.context, string(action.Body), stdout, stderr)
+	s.mu.Unlock()
+	if stderr.Len() > 0 {
+		return status.Error(codes.InvalidArgument, strings.TrimSpace(stderr.String()))
+	}
+	return stream.Send(&flight.Result{Body: stdout.Bytes()})
+}
+
+// ListActions implements flight.FlightServer.
+func (s *FlightServer) ListActions(_ *flight.Empty, stream flight.FlightService_ListActionsServer) error {
+	return stream.Send(&flight.ActionType{
+		Type:        EvalAction,
+		Description: "evaluate the ivy program in the action body",
+	})
+}
+
+// DoGet implements flight.FlightServer.
+func (s *FlightServer) DoGet(ticket *flight.Ticket, stream flight.FlightService_DoGetServer) error {
+	name := string(ticket.Ticket)
+	s.mu.Lock()
+	v := s.context.Global(name)
+	s.mu.Unlock()
+	if v == nil {
+		return status.Errorf(codes.NotFound, "no variable %q", name)
+	}
+	schema, recs, err := Records(name, v, s.mem)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s: %v", name, err)
+	}
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	w := flight.NewRecordWriter(stream, ipc.WithSchema(schema))
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// Records converts the value to a single column with the given name and
+// returns the schema and the record batches, one per chunk of the column.
+// The caller must release the records.
+func Records(name string, v value.Value, mem memory.Allocator) (schema *arrow.Schema, recs []arrow.Record, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("cannot convert to arrow: %v", e)
+		}
+	}()
+	col := value.ToArrowColumn(v, mem)
+	if col == nil {
+		return nil, nil, fmt.Errorf("cannot convert %T to arrow", v)
+	}
+	// The records hold their own references to the chunks.
+	defer col.Release()
+	schema = arrow.NewSchema([]arrow.Field{{Name: name, Type: col.DataType()}}, nil)
+	for _, chunk := range col.Data().Chunks() {
+		recs = append(recs, array.NewRecord(schema, []arrow.Array{chunk}, int64(chunk.Len())))
+	}
+	return schema, recs, nil
+}
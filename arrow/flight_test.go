@@ -0,0 +1,123 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package arrow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/flight"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"robpike.io/ivy/config"
+)
+
+func TestFlightServer(t *testing.T) {
+	var conf config.Config
+	srv := flight.NewServerWithMiddleware(nil)
+	if err := srv.Init("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	srv.RegisterFlightService(NewFlightServer(&conf))
+	go srv.Serve()
+	defer srv.Shutdown()
+
+	client, err := flight.NewClientWithMiddleware(srv.Addr().String(), nil, nil, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// Upload a table.
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "x", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "y", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2, 3}, nil)
+	b.Field(1).(*array.Int64Builder).AppendValues([]int64{10, 20, 30}, nil)
+	rec := b.NewRecord()
+	defer rec.Release()
+	put, err := client.DoPut(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := flight.NewRecordWriter(put, ipc.WithSchema(schema))
+	w.SetFlightDescriptor(&flight.FlightDescriptor{Type: flight.DescriptorPATH, Path: []string{"table"}})
+	if err := w.Write(rec); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	put.CloseSend()
+	if _, err := put.Recv(); err != nil {
+		t.Fatalf("DoPut: %v", err)
+	}
+
+	// Evaluate a program using the table.
+	action, err := client.DoAction(ctx, &flight.Action{Type: EvalAction, Body: []byte("z = x + y\n+/z")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := action.Recv()
+	if err != nil {
+		t.Fatalf("DoAction: %v", err)
+	}
+	if got := string(result.Body); got != "66\n" {
+		t.Errorf("DoAction: got %q; want %q", got, "66\n")
+	}
+
+	// Errors are reported.
+	action, err = client.DoAction(ctx, &flight.Action{Type: EvalAction, Body: []byte("1/0")})
+	if err == nil {
+		_, err = action.Recv()
+	}
+	if err == nil {
+		t.Errorf("DoAction of 1/0 succeeded")
+	}
+
+	// Special commands are refused, so clients cannot reach the server's files.
+	file := filepath.Join(t.TempDir(), "saved")
+	action, err = client.DoAction(ctx, &flight.Action{Type: EvalAction, Body: []byte(")save " + file)})
+	if err == nil {
+		_, err = action.Recv()
+	}
+	if err == nil || !strings.Contains(err.Error(), "special commands are disabled") {
+		t.Errorf("DoAction of )save: got error %v; want special commands are disabled", err)
+	}
+	if _, err := os.Stat(file); err == nil {
+		t.Errorf("DoAction of )save wrote %s", file)
+	}
+
+	// Fetch the result.
+	get, err := client.DoGet(ctx, &flight.Ticket{Ticket: []byte("z")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdr, err := flight.NewRecordReader(get)
+	if err != nil {
+		t.Fatalf("DoGet: %v", err)
+	}
+	defer rdr.Release()
+	if name := rdr.Schema().Field(0).Name; name != "z" {
+		t.Errorf("DoGet: column %q; want z", name)
+	}
+	var got []int64
+	for rdr.Next() {
+		got = append(got, rdr.Record().Column(0).(*array.Int64).Int64Values()...)
+	}
+	if want := []int64{11, 22, 33}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DoGet: got %v; want %v", got, want)
+	}
+}
@@ -22,6 +22,7 @@ var DebugFlags = [...]string{
 	"parse",
 	"tokens",
 	"types",
+	"vm",
 }
 
 // A Config holds information about the configuration of the system.
@@ -45,6 +46,7 @@ type Config struct {
 	maxDigits   uint          // Above this size, ints print in floating format.
 	maxStack    uint          // Maximum call stack depth.
 	floatPrec   uint          // Length of mantissa of a BigFloat.
+	float64     bool          // Floating-point values are float64, not BigFloat.
 	realTime    time.Duration // Elapsed time of last interactive command.
 	userTime    time.Duration // User time of last interactive command.
 	sysTime     time.Duration // System time of last interactive command.
@@ -53,6 +55,8 @@ type Config struct {
 	outputBase int
 	mobile     bool // Running on a mobile platform.
 	embedded   bool // running in something else
+	jsonOutput bool // Print results as JSON.
+	noSpecial  bool // Special commands are disabled.
 }
 
 func (c *Config) init() {
@@ -215,6 +219,18 @@ func (c *Config) SetRandomSeed(seed int64) {
 	c.source.Seed(seed)
 }
 
+// Float64 reports whether floating-point values are native float64s
+// with IEEE semantics rather than arbitrary-precision BigFloats.
+func (c *Config) Float64() bool {
+	return c.float64
+}
+
+// SetFloat64 sets whether floating-point values are native float64s.
+func (c *Config) SetFloat64(f bool) {
+	c.init()
+	c.float64 = f
+}
+
 // MaxBits returns the maximum integer size to store, in bits.
 func (c *Config) MaxBits() uint {
 	c.init()
@@ -360,3 +376,26 @@ func (c *Config) SetMobile(mobile bool) {
 	c.init()
 	c.mobile = mobile
 }
+
+// JSONOutput reports whether results are printed as JSON.
+func (c *Config) JSONOutput() bool {
+	return c.jsonOutput
+}
+
+// SetJSONOutput sets whether results are printed as JSON.
+func (c *Config) SetJSONOutput(json bool) {
+	c.init()
+	c.jsonOutput = json
+}
+
+// SpecialCommands reports whether special commands such as )get are allowed.
+func (c *Config) SpecialCommands() bool {
+	return !c.noSpecial
+}
+
+// SetSpecialCommands sets whether special commands are allowed. Servers
+// disable them, as they read and write files on the server's machine.
+func (c *Config) SetSpecialCommands(allow bool) {
+	c.init()
+	c.noSpecial = !allow
+}
@@ -70,6 +70,10 @@ Unary operators
 
 	Name              APL   Ivy     Meaning
 	Roll              ?B    ?       One integer selected randomly from the first B integers
+	Uniform                 uniform Array of shape B of floats chosen randomly from [0, 1)
+	Normal                  normal  Array of shape B of normally distributed floats, mean 0 and standard deviation 1
+	Exponential             expo    Array of shape B of exponentially distributed floats, mean 1
+	Shuffle                 shuffle The items of B in random order
 	Ceiling           ⌈B    ceil    Least integer greater than or equal to B
 	Floor             ⌊B    floor   Greatest integer less than or equal to B
 	Shape             ⍴B    rho     Number of components in each dimension of B
@@ -82,16 +86,26 @@ Unary operators
 	Signum            ×B    sgn     ¯1 if B<0; 0 if B=0; 1 if B>0
 	Reciprocal        ÷B    /       1 divided by B
 	Ravel             ,B    ,       Reshapes B into a vector
-	Matrix inverse    ⌹B            Inverse of matrix B
+	Matrix inverse    ⌹B    inv     Inverse of matrix B; pseudo-inverse if B has more rows than columns
 	Pi times          ○B            Multiply by π
 	Logarithm         ⍟B    log     Natural logarithm of B
 	Reversal          ⌽B    rot     Reverse elements of B along last axis
 	Reversal          ⊖B    flip    Reverse elements of B along first axis
 	Grade up          ⍋B    up      Indices of B which will arrange B in ascending order
 	Grade down        ⍒B    down    Indices of B which will arrange B in descending order
+	Unique            ∪B    unique  Elements of B without repeats, in order of first occurrence
+	Count                   count   Matrix of the distinct elements of B and the number of times each occurs
+	Mean                    mean    Arithmetic mean of B; of each column if B is a matrix
+	Median                  median  Middle value of B, or the mean of the two middle values
+	Mode                    mode    Most frequent element of B, the first to occur if there is a tie
+	Variance                var     Sample variance of B, dividing by one less than the count
+	Standard deviation      sdev    Square root of the sample variance of B
 	Execute           ⍎B    ivy     Execute an APL (ivy) expression
 	Monadic format    ⍕B    text    A character representation of B
 	Monadic transpose ⍉B    transp  Reverse the axes of B
+	Enclose           ⊂B    box     A scalar holding B, so it can be an element of a vector
+	Disclose          ⊃B    unbox   The contents of box B; boxes in B are mixed into one array
+	Depth             ≡B    depth   Levels of nesting of boxes in B; 0 for a simple scalar
 	Factorial         !B    !       Product of integers 1 to B
 	Bitwise not             ^       Bitwise complement of B (integer only)
 	Square root       B⋆.5  sqrt    Square root of B.
@@ -111,6 +125,7 @@ Unary operators
 	Real part               real    Real component of the value
 	Imaginary part          imag    Imaginary component of the value
 	Phase                   phase   Phase of the value in the complex plane (-π to π)
+	Raise error             raise   Fail with the text of B as the error message
 
 Binary operators
 
@@ -128,7 +143,29 @@ Binary operators
 	                            cos     cos(B); ivy uses traditional name.
 	                            tan     tan(B); ivy uses traditional name.
 	Deal                  A?B   ?       A distinct integers selected randomly from the first B integers
+	Uniform                     uniform Array of shape B of floats chosen randomly from [0, A), or from
+	                                    [A[1], A[2]) if A has two elements
+	Normal                      normal  Array of shape B of normally distributed floats with mean A[1]
+	                                    and standard deviation A[2], or 1 if A has one element
+	Exponential                 expo    Array of shape B of exponentially distributed floats with rate A
+	Poisson                     poisson Array of shape B of integers with a Poisson distribution of mean A
+	Binomial                    binomial  Array of shape B of the numbers of successes in A[1] trials
+	                                    each with probability A[2]
+	Weighted sample             sample  Array of shape B of indices of A chosen randomly, with replacement,
+	                                    with probability proportional to the weights A
 	Membership            A∈B   in      1 for elements of A present in B; 0 where not.
+	Union                 A∪B   union   A followed by the elements of B not in A
+	Intersection          A∩B   intersect  Elements of A that are also in B
+	Without               A~B   without Elements of A that are not in B
+	Interval index        A⍸B   bin     Index of the interval between the ascending edges A holding each
+	                                    element of B: the number of edges ≤ B, less 1 if origin is 0
+	Quantile                    quantile  Value below which fraction A of the data B lies,
+	                                    interpolating between elements; of each column of a matrix
+	Percentile                  percentile  Value below which A percent of the data B lies
+	Covariance                  cov     Sample covariance of A and B
+	Correlation                 corr    Correlation coefficient of A and B
+	Windows                     window  Matrix whose columns are the windows of A elements of B;
+	                                    median 3 window B gives the rolling median of B
 	Maximum               A⌈B   max     The greater value of A or B
 	Minimum               A⌊B   min     The smaller value of A or B
 	Reshape               A⍴B   rho     Array of shape A with data B
@@ -146,7 +183,8 @@ Binary operators
 	                                    In ivy: abs(A) gives count, A <= 0 inserts zero
 	Index of              A⍳B   iota    The location (index) of B in A; 1+⌈/⍳⍴A if not found
 	                                    In ivy: origin-1 if not found (i.e. 0 if one-indexed)
-	Matrix divide         A⌹B           Solution to system of linear equations Ax = B
+	Matrix divide         A⌹B   mdiv    Solution to system of linear equations Ax = B
+	                                    In ivy: least-squares solution if A has more rows than columns
 	Rotation              A⌽B   rot     The elements of B are rotated A positions left
 	Rotation              A⊖B   flip    The elements of B are rotated A positions along the first axis
 	Logarithm             A⍟B   log     Logarithm of B to base A
@@ -179,12 +217,19 @@ Operators and axis indicator
 
 	Name                APL  Ivy  APL Example  Ivy Example  Meaning (of example)
 	Reduce (last axis)  /    /    +/B          +/B          Sum across B
-	Reduce (first axis) ⌿         +⌿B                       Sum down B
+	N-wise reduce       /    /    N+/B         N +/ B       Sums of windows of N elements of B
+	                                                    (each window is taken backwards if N is negative)
+	Reduce (first axis) ⌿    /%   +⌿B          +/%B         Sum down B
 	Scan (last axis)    \    \    +\B          +\B          Running sum across B
-	Scan (first axis)   ⍀         +⍀B                       Running sum down B
+	Scan (first axis)   ⍀    \%   +⍀B          +\%B         Running sum down B
 	Inner product       .    .    A+.×B        A +.* B      Matrix product of A and B
 	Outer product       ∘.   o.   A∘.×B        A o.* B      Outer product of A and B
 	                                                    (lower case o; may need preceding space)
+	Each                ¨    @    f¨B          f@ B         Apply f to each item of B
+	                                                    (A f@ B applies f to items of A and B)
+	Power               ⍣    power f⍣N B       N f power B  Apply f to B N times
+	                                                    (f power B applies f until the result stops changing;
+	                                                    (g) f power B until new g previous is true)
 
 Type-converting operations
 
@@ -194,6 +239,10 @@ Type-converting operations
 	Float                   float B The floating-point representation of B;
 	                                for complex numbers, the result is
 	                                (float A)j(float B)
+	JSON                    json B  The JSON representation of B, as a string
+	                                (see "JSON" below)
+	From JSON               fromjson B
+	                                The value represented by the JSON string B
 
 Pre-defined constants
 
@@ -224,6 +273,41 @@ legal but arithmetic is not, and chars cannot be converted automatically into ot
 singleton values (ints, floats, and so on). The unary operators char and code
 enable transcoding between integer and char values.
 
+Boxes
+
+The elements of vectors and matrices are scalars. To hold values of other
+shapes, such as a list of names of differing lengths, enclose them in a box,
+which is a scalar. Boxes print with a frame around their contents.
+
+	names = (box 'ann') (box 'bob') (box 'carol')
+	rho names
+	result: 3
+	unbox names[3]
+	result: carol
+
+Elementwise operators apply to the contents of boxes and box the result, so
+(box 1 2) + 10 is (box 11 12). Unbox of a vector or matrix of boxes mixes the
+contents into an array with an extra axis, padding shorter items with zeros
+(blanks for chars).
+
+JSON
+
+The unary operators json and fromjson convert between values and JSON text.
+Integers become JSON numbers, floats become JSON numbers with a decimal point
+or exponent, and a char or vector of chars becomes a JSON string. Other vectors
+become JSON arrays. Rationals, complex numbers, and matrices become objects:
+
+	json 1/3
+	result: {"rat": "1/3"}
+	json 1j2
+	result: {"re": 1, "im": 2}
+	json 2 3 rho iota 6
+	result: {"shape": [2, 3], "data": [[1, 2, 3], [4, 5, 6]]}
+
+When converting from JSON, true and false become 1 and 0, null is an error,
+and nested arrays of equal length become a matrix. The -json flag prints each
+result as JSON on a line of its own, for use by other programs.
+
 User-defined operators
 
 Users can define unary and binary operators, which then behave just like
@@ -281,6 +365,38 @@ Example: greatest common divisor (binary):
 	1562 gcd !11
 	result: 22
 
+The lines of a multiline operator may include loops, which repeat the
+lines between the loop's first line and a line holding ":end". A ":while"
+loop runs while its scalar condition is non-zero; a ":for" loop assigns each
+item of a vector, or each row of a matrix, to its variable in turn. Within a
+loop, ":break" leaves the loop and ":continue" starts its next iteration.
+Either may be the right operand of ":", and a ":" return inside a loop
+returns from the operator.
+
+Example: factorial with a loop (unary):
+	op fac n =
+		r = 1
+		:while n > 1
+			r = r*n
+			n = n-1
+		:end
+		r
+
+	fac 10
+	result: 3628800
+
+Example: sum of the elements up to the first negative one (unary):
+	op total v =
+		t = 0
+		:for x in v
+			x < 0: :break
+			t = t+x
+		:end
+		t
+
+	total 1 2 3 -4 5
+	result: 6
+
 On mobile platforms only, due to I/O restrictions, user-defined operators
 must be presented on a single line. Use semicolons to separate expressions:
 
@@ -310,6 +426,111 @@ To write to a global without reading it first, insert an unused read.
 	total last
 	result: 12 3
 
+Anonymous operators
+
+An operator may also be written in place, without a name, as a body in braces.
+Within the body, x is the argument of a unary operator and y is absent; for a
+binary operator, x is the left argument and y the right. As with 'op', the
+body may hold several expressions separated by semicolons, including ":"
+conditional returns. An anonymous operator can be used wherever an operator
+can, including in reductions, scans, products and with each:
+
+	{x*2} 1 2 3
+	result: 2 4 6
+	{x max y}/ 3 1 4 1 5
+	result: 5
+	1 2 3 +.{x*y} 4 5 6
+	result: 32
+
+An anonymous operator that is not applied is a value, which can be assigned to
+a variable. The variable may then be used as an operator, and may refer to
+itself:
+
+	fact = {x <= 1: 1; x * fact x-1}
+	fact 5
+	result: 120
+
+Within a user-defined operator, an anonymous operator captures the values of
+the local variables it reads, as they are when it is evaluated, so it can be
+returned as the result:
+
+	op scale n = {x*n}
+	triple = scale 3
+	triple 1 2 3
+	result: 3 6 9
+
+Higher-order operators
+
+A user-defined operator may take an operator as an operand, written in
+parentheses before its name in the definition. Within the body, the operand
+is used like any other operator. When the higher-order operator is applied,
+the operand, which may be built-in, user-defined or anonymous, is written
+before its name:
+
+	op (f) twice x = f f x
+	{x*2} twice 3
+	result: 12
+	op a (f) both b = (a f b) (b f a)
+	3 - both 10
+	result: -7 7
+
+An operator that uses its operand in a reduction:
+
+	op (f) fold v = f/ v
+	max fold 3 1 4 1 5
+	result: 5
+
+Repeated application until the result stops changing:
+
+	op (f) fix x =
+		y = f x
+		y == x: x
+		f fix y
+
+	{floor x/2} fix 100
+	result: 0
+
+The built-in higher-order op power does this directly. With a left
+argument, it applies its operand that many times; without one, it applies
+it until the result stops changing, comparing floating-point results to
+within the last few bits of precision. If the left argument is itself an
+op, it is a condition: the operand is applied until the condition, given
+the new result as its left argument and the previous one as its right,
+is true. Power gives up with an error after about a million iterations.
+
+	{floor x/2} power 100
+	result: 0
+	3 sqrt power 256
+	result: 2
+	{(x + 2/x)/2} power float 1.5
+	result: 1.41421356237
+	({x > 100}) {x*2} power 1
+	result: 128
+
+Trapping errors
+
+An expression that may fail can be trapped with try, written
+
+	try expression : fallback
+
+If the expression succeeds, its value is the result. If it fails, such as
+by dividing by zero or with a length mismatch, the fallback is evaluated
+instead. The ":" separates the two and binds more loosely than any operator,
+so parenthesize a try within a larger expression. If the fallback is an
+anonymous operator, it is applied to the error message as a char vector.
+The raise operator fails with an error of one's own.
+
+	try 1 / 0 : 0
+	result: 0
+	try 1 2 + 3 4 5 : {'caught: ', x}
+	result: caught: length mismatch: 2 3
+	op check n =
+		n < 0: raise 'negative: ', text n
+		sqrt n
+
+	try check -4 : {x}
+	result: negative: -4
+
 Special commands
 
 Ivy accepts a number of special commands, introduced by a right paren
@@ -332,9 +553,24 @@ base 10 and must be non-negative on input.
 	) debug name 0|1
 		Toggle or set the named debugging flag. With no argument, lists
 		the settings.
+		User-defined ops are compiled to bytecode when defined. The vm
+		flag prints the bytecode of each op as it is defined and checks
+		the result of each call against that of walking the op's parse
+		tree, which runs the op twice. Ops that may do more than compute
+		a value, such as by assigning to a global, are run only once and
+		not checked.
 	) demo
 		Run a line-by-line interactive demo. On mobile platforms,
 		use the Demo menu option instead.
+	) float64 0
+		If 1, floating-point values are native 64-bit floats with IEEE
+		semantics, so for instance division of a float by zero, log 0
+		and atanh 1 give infinities. Arithmetic on them, and on Arrow
+		floating-point columns, runs at hardware speed. Exact values
+		remain exact, so 1/0 is still an error, and results that are not
+		real, such as sqrt -1 and log -1, are complex, as they are
+		otherwise, rather than NaN. If 0, the default, floating-point
+		values have the precision set by ) prec.
 	) format ""
 		Set the format for printing values. If empty, the output is printed
 		using the output base. If non-empty, the format determines the
@@ -373,7 +609,9 @@ base 10 and must be non-negative on input.
 		"save.ivy".
 		(Unimplemented on mobile.)
 	) seed 0
-		Set the seed for the ? operator.
+		Set the seed for the ? operator and the random distributions.
+		A given seed produces the same values however much of the
+		computation is done in parallel.
 
 */
 package main
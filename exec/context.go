@@ -8,6 +8,7 @@ import (
 	"context"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apache/arrow/go/v10/arrow"
 	"github.com/apache/arrow/go/v10/arrow/memory"
@@ -29,7 +30,8 @@ type Context struct {
 	// Accessed through the value.Context Config method.
 	config *config.Config
 
-	frameSizes []int // size of each stack frame on the call stack
+	frameSizes []int                   // size of each stack frame on the call stack
+	frameOps   []map[string]value.Func // ops bound in the caller of each frame
 	stack      []value.Value
 
 	Globals Symtab
@@ -43,6 +45,22 @@ type Context struct {
 	Defs []OpDef
 	// Names of variables declared in the currently-being-parsed function.
 	variables []string
+	// Names of variables assigned op values in the currently-being-parsed
+	// function or line, which may therefore be used as operators.
+	opVars []string
+	// Names of the op operands of the higher-order op being parsed.
+	opArgs []string
+	// ops holds the op values bound to names while evaluating
+	// an expression that uses them as operators. Each call of an
+	// op starts with none, so the bindings are not seen by the ops
+	// the expression calls.
+	ops map[string]value.Func
+	// checking records whether the VM is being checked against the
+	// tree walker, and which of them is running; see evalBody.
+	checking int
+	// deadline, if not zero, is the time after which evaluation
+	// stops with an error; see SetDeadline.
+	deadline time.Time
 
 	pool memory.Allocator
 }
@@ -64,6 +82,20 @@ func (c *Context) Config() *config.Config {
 	return c.config
 }
 
+// SetDeadline sets the time after which evaluation stops with an error.
+// It is checked as ops are called and loops iterate, so a builtin op on
+// a large value still runs to completion. The zero time means no deadline.
+func (c *Context) SetDeadline(t time.Time) {
+	c.deadline = t
+}
+
+// checkDeadline stops evaluation if the deadline has passed.
+func (c *Context) checkDeadline() {
+	if !c.deadline.IsZero() && time.Now().After(c.deadline) {
+		value.Errorf("evaluation time limit exceeded")
+	}
+}
+
 // SetConstants re-assigns the fundamental constant values using the current
 // setting of floating-point precision.
 func (c *Context) SetConstants() {
@@ -102,6 +134,8 @@ func (c *Context) push(fn *Function) {
 	}
 	c.frameSizes = append(c.frameSizes, len(fn.Locals))
 	c.stack = c.stack[:n+len(fn.Locals)]
+	c.frameOps = append(c.frameOps, c.ops)
+	c.ops = nil
 }
 
 // pop pops the top frame from the stack.
@@ -109,6 +143,8 @@ func (c *Context) pop() {
 	n := c.frameSizes[len(c.frameSizes)-1]
 	c.frameSizes = c.frameSizes[:len(c.frameSizes)-1]
 	c.stack = c.stack[:len(c.stack)-n]
+	c.ops = c.frameOps[len(c.frameOps)-1]
+	c.frameOps = c.frameOps[:len(c.frameOps)-1]
 }
 
 // Eval evaluates a list of expressions.
@@ -123,8 +159,19 @@ func (c *Context) Eval(exprs []value.Expr) []value.Value {
 	return values
 }
 
-// EvalUnary evaluates a unary operator, including reductions and scans.
+// EvalUnary evaluates a unary operator, including reductions, scans and each.
 func (c *Context) EvalUnary(op string, right value.Value) value.Value {
+	if len(op) > 1 && op[len(op)-1] == '@' {
+		return value.Each(c, op[:len(op)-1], right)
+	}
+	if len(op) > 2 {
+		switch op[len(op)-2:] {
+		case "/%":
+			return value.ReduceFirst(c, op[:len(op)-2], right)
+		case "\\%":
+			return value.ScanFirst(c, op[:len(op)-2], right)
+		}
+	}
 	if len(op) > 1 {
 		switch op[len(op)-1] {
 		case '/':
@@ -141,6 +188,9 @@ func (c *Context) EvalUnary(op string, right value.Value) value.Value {
 }
 
 func (c *Context) Unary(op string) value.UnaryOp {
+	if fn, ok := c.ops[op]; ok {
+		return fn
+	}
 	userFn := c.UnaryFn[op]
 	if userFn != nil {
 		return userFn
@@ -153,14 +203,24 @@ func (c *Context) Unary(op string) value.UnaryOp {
 }
 
 func (c *Context) UserDefined(op string, isBinary bool) bool {
+	if c.OpVariable(op) || c.OpArg(op) {
+		return true
+	}
 	if isBinary {
 		return c.BinaryFn[op] != nil
 	}
 	return c.UnaryFn[op] != nil
 }
 
-// EvalBinary evaluates a binary operator, including products.
+// EvalBinary evaluates a binary operator, including products, n-wise
+// reductions and each.
 func (c *Context) EvalBinary(left value.Value, op string, right value.Value) value.Value {
+	if len(op) > 1 && op[len(op)-1] == '@' {
+		return value.EachBinary(c, left, op[:len(op)-1], right)
+	}
+	if len(op) > 1 && op[len(op)-1] == '/' {
+		return value.NwiseReduce(c, left, op[:len(op)-1], right)
+	}
 	if strings.Contains(op, ".") {
 		return value.Product(c, left, op, right)
 	}
@@ -172,6 +232,9 @@ func (c *Context) EvalBinary(left value.Value, op string, right value.Value) val
 }
 
 func (c *Context) Binary(op string) value.BinaryOp {
+	if fn, ok := c.ops[op]; ok {
+		return fn
+	}
 	user := c.BinaryFn[op]
 	if user != nil {
 		return user
@@ -254,6 +317,136 @@ func (c *Context) Declare(name string) {
 // ForgetAll forgets the declared variables.
 func (c *Context) ForgetAll() {
 	c.variables = nil
+	c.opVars = nil
+	c.opArgs = nil
+}
+
+// Declared returns the number of variables declared so far.
+func (c *Context) Declared() int {
+	return len(c.variables)
+}
+
+// Forget forgets the variables declared after the first n,
+// such as the arguments of an anonymous op once it has been parsed.
+func (c *Context) Forget(n int) {
+	c.variables = c.variables[:n]
+}
+
+// DeclareOp records that the variable is assigned an op value
+// while parsing, so it may be used as an operator.
+func (c *Context) DeclareOp(name string) {
+	c.opVars = append(c.opVars, name)
+}
+
+// DeclareOpArg makes the name the op operand of the higher-order
+// op being parsed, so it may be used as an operator in the body.
+func (c *Context) DeclareOpArg(name string) {
+	c.opArgs = append(c.opArgs, name)
+}
+
+// OpArg reports whether the name is the op operand of the
+// higher-order op being parsed.
+func (c *Context) OpArg(name string) bool {
+	if c.isVariable(name) {
+		return false
+	}
+	for _, s := range c.opArgs {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
+
+// HigherOrder reports whether the operator is a higher-order op,
+// one that takes an op operand.
+func (c *Context) HigherOrder(op string, isBinary bool) bool {
+	if c.isVariable(op) {
+		return false
+	}
+	fn := c.UnaryFn[op]
+	if isBinary {
+		fn = c.BinaryFn[op]
+	}
+	if fn != nil {
+		return fn.OpArg != ""
+	}
+	return op == "power"
+}
+
+// EvalHigher applies the higher-order op, with fn as its op operand.
+// For a unary op, left is nil.
+func (c *Context) EvalHigher(left value.Value, op string, fn value.Func, right value.Value) value.Value {
+	var def *Function
+	if left == nil {
+		def = c.UnaryFn[op]
+	} else {
+		def = c.BinaryFn[op]
+	}
+	if def == nil && op == "power" {
+		// The built-in power op: n f power v applies f n times,
+		// and f power v applies it until the result stops changing.
+		if left == nil {
+			return value.Fixpoint(c, fn, right)
+		}
+		return value.Power(c, fn, left, right)
+	}
+	if def == nil || def.OpArg == "" {
+		value.Errorf("%s is not a higher-order op", op)
+	}
+	if def.Body == nil {
+		value.Errorf("%q undefined", op)
+	}
+	return def.apply(c, left, right, fn)
+}
+
+// ForgetOp undoes the most recent DeclareOp of the name.
+func (c *Context) ForgetOp(name string) {
+	for i := len(c.opVars) - 1; i >= 0; i-- {
+		if c.opVars[i] == name {
+			c.opVars = append(c.opVars[:i], c.opVars[i+1:]...)
+			return
+		}
+	}
+}
+
+// OpVariable reports whether the name is a variable that holds,
+// or has been declared to hold, an op value.
+func (c *Context) OpVariable(name string) bool {
+	if c.isVariable(name) {
+		return false
+	}
+	for _, s := range c.opVars {
+		if name == s {
+			return true
+		}
+	}
+	_, ok := c.Globals[name].(value.Func)
+	return ok
+}
+
+// BindOp binds the name to the op value fn while evaluating an
+// expression that uses it as an operator. It returns a function
+// that restores the previous binding.
+func (c *Context) BindOp(name string, fn value.Func) func() {
+	if c.ops == nil {
+		c.ops = make(map[string]value.Func)
+	}
+	prev, ok := c.ops[name]
+	c.ops[name] = fn
+	return func() {
+		if ok {
+			c.ops[name] = prev
+		} else {
+			delete(c.ops, name)
+		}
+	}
+}
+
+// Variable reports whether the name is declared as a variable while
+// parsing: an argument of the op being defined or of an anonymous op.
+func (c *Context) Variable(name string) bool {
+	return c.isVariable(name)
 }
 
 func (c *Context) isVariable(op string) bool {
@@ -301,6 +494,10 @@ func (c *Context) LoadGlobalsFromParquet(fileName string, config config.Config)
 }
 */
 
+// LoadGlobalsFromTable assigns each numeric column of the table to the global
+// variable with the column's name. If resolver is nil, each column gets a
+// value.ChunkResolver for its own chunk layout. The variables retain their
+// columns, so the caller may release the table.
 func (c *Context) LoadGlobalsFromTable(table arrow.Table, config *config.Config, resolver value.Resolver) error {
 	if table == nil {
 		return nil // nothoing to load
@@ -313,7 +510,12 @@ func (c *Context) LoadGlobalsFromTable(table arrow.Table, config *config.Config,
 		case arrow.BinaryTypes.Binary:
 			// skip
 		default:
-			c.AssignGlobal(col.Name(), value.NewArrowVector(col, config, resolver))
+			r := resolver
+			if r == nil {
+				r = value.NewChunkResolver(col)
+			}
+			col.Retain()
+			c.AssignGlobal(col.Name(), value.NewArrowVector(col, config, r))
 		}
 	}
 	return nil
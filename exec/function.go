@@ -6,19 +6,25 @@ package exec
 
 import (
 	"fmt"
+	"strings"
 
 	"robpike.io/ivy/value"
 )
 
 // Function represents a unary or binary user-defined operator.
+// A higher-order operator also has an op operand, named by OpArg.
 type Function struct {
 	IsBinary bool
 	Name     string
+	OpArg    string
 	Left     string
 	Right    string
 	Body     []value.Expr
 	Locals   []string
 	Globals  []string
+	Code     *Code   // Body compiled for the VM, if it has been.
+	Calls    []OpDef // The user-defined ops the body calls.
+	Effects  bool    // Whether the body does more than compute a value, apart from what the ops it calls do.
 }
 
 func (fn *Function) String() string {
@@ -26,12 +32,16 @@ func (fn *Function) String() string {
 	if fn.IsBinary {
 		left = fn.Left + " "
 	}
+	if fn.OpArg != "" {
+		left += "(" + fn.OpArg + ") "
+	}
 	s := fmt.Sprintf("op %s%s %s =", left, fn.Name, fn.Right)
-	if len(fn.Body) == 1 {
+	if len(fn.Body) == 1 && !strings.Contains(fn.Body[0].ProgString(), "\n") {
 		return s + " " + fn.Body[0].ProgString()
 	}
 	for _, stmt := range fn.Body {
-		s += "\n\t" + stmt.ProgString()
+		// Indent the lines of loop bodies too.
+		s += "\n\t" + strings.ReplaceAll(stmt.ProgString(), "\n", "\n\t")
 	}
 	return s
 }
@@ -41,36 +51,125 @@ func (fn *Function) EvalUnary(context value.Context, right value.Value) value.Va
 		value.Errorf("unary %q undefined", fn.Name)
 	}
 	// It's known to be an exec.Context.
-	c := context.(*Context)
+	return fn.apply(context.(*Context), nil, right, nil)
+}
+
+func (fn *Function) EvalBinary(context value.Context, left, right value.Value) value.Value {
+	if fn.Body == nil {
+		value.Errorf("binary %q undefined", fn.Name)
+	}
+	// It's known to be an exec.Context.
+	return fn.apply(context.(*Context), left, right, nil)
+}
+
+// apply calls fn with the arguments; left is nil for a unary op. The op
+// operand of a higher-order op is held in the local after the arguments,
+// where the references to it in the body find it.
+func (fn *Function) apply(c *Context, left, right value.Value, operand value.Value) value.Value {
 	if uint(len(c.frameSizes)) >= c.config.MaxStack() {
 		value.Errorf("stack overflow calling %q", fn.Name)
 	}
 	c.push(fn)
 	defer c.pop()
-	c.AssignLocal(1, right)
-	v := value.EvalFunctionBody(c, fn.Name, fn.Body)
+	n := 1
+	if fn.IsBinary {
+		c.AssignLocal(n, left)
+		n++
+	}
+	c.AssignLocal(n, right)
+	if fn.OpArg != "" {
+		c.AssignLocal(n+1, operand)
+	}
+	v := c.evalBody(fn)
 	if v == nil {
 		value.Errorf("no value returned by %q", fn.Name)
 	}
 	return v
 }
 
-func (fn *Function) EvalBinary(context value.Context, left, right value.Value) value.Value {
-	if fn.Body == nil {
-		value.Errorf("binary %q undefined", fn.Name)
-	}
+// Closure is an anonymous op, such as {x*2}, together with the values
+// it captured from the locals of the op in which it was created. Its
+// Function has locals x, the right or only argument, and y, the right
+// argument when x is the left, followed by the captured variables.
+type Closure struct {
+	Fn       *Function
+	Captured []value.Value
+}
+
+func (cl *Closure) EvalUnary(context value.Context, right value.Value) value.Value {
+	return cl.eval(context, right, nil)
+}
+
+func (cl *Closure) EvalBinary(context value.Context, left, right value.Value) value.Value {
+	return cl.eval(context, left, right)
+}
+
+func (cl *Closure) eval(context value.Context, x, y value.Value) value.Value {
 	// It's known to be an exec.Context.
 	c := context.(*Context)
 	if uint(len(c.frameSizes)) >= c.config.MaxStack() {
-		value.Errorf("stack overflow calling %q", fn.Name)
+		value.Errorf("stack overflow calling %s", cl.Fn.Name)
 	}
-	c.push(fn)
+	c.push(cl.Fn)
 	defer c.pop()
-	c.AssignLocal(1, left)
-	c.AssignLocal(2, right)
-	v := value.EvalFunctionBody(c, fn.Name, fn.Body)
+	c.AssignLocal(1, x)
+	c.AssignLocal(2, y)
+	for i, v := range cl.Captured {
+		c.AssignLocal(3+i, v)
+	}
+	v := c.evalBody(cl.Fn)
 	if v == nil {
-		value.Errorf("no value returned by %q", fn.Name)
+		value.Errorf("no value returned by %s", cl.Fn.Name)
 	}
 	return v
 }
+
+// OpRef is an op value that applies an operator by name, such as +/ or
+// a user-defined op. It keeps the op values that were bound to the names
+// it uses when it was made, so a higher-order op can pass its own op
+// operand to another.
+type OpRef struct {
+	Op    string
+	Bound map[string]value.Func
+}
+
+// NewOpRef returns an op value that applies op, which uses the operators
+// with the given names. The text is its source, used to print it.
+func (c *Context) NewOpRef(op string, names []string, text string) value.Func {
+	ref := &OpRef{Op: op}
+	for _, name := range names {
+		if fn, ok := c.ops[name]; ok {
+			if ref.Bound == nil {
+				ref.Bound = make(map[string]value.Func)
+			}
+			ref.Bound[name] = fn
+		}
+	}
+	return value.NewFunc(ref, text)
+}
+
+func (r *OpRef) EvalUnary(context value.Context, right value.Value) value.Value {
+	c := context.(*Context)
+	defer r.bind(c)()
+	return c.EvalUnary(r.Op, right)
+}
+
+func (r *OpRef) EvalBinary(context value.Context, left, right value.Value) value.Value {
+	c := context.(*Context)
+	defer r.bind(c)()
+	return c.EvalBinary(left, r.Op, right)
+}
+
+// bind restores the bindings of the names used by r and
+// returns a function that undoes them.
+func (r *OpRef) bind(c *Context) func() {
+	var restore []func()
+	for name, fn := range r.Bound {
+		restore = append(restore, c.BindOp(name, fn))
+	}
+	return func() {
+		for _, f := range restore {
+			f()
+		}
+	}
+}
@@ -16,7 +16,7 @@ func (c *Context) DefinedOp(op string) bool {
 	if c.isVariable(op) {
 		return false
 	}
-	return Predefined(op) || c.BinaryFn[op] != nil || c.UnaryFn[op] != nil
+	return Predefined(op) || c.BinaryFn[op] != nil || c.UnaryFn[op] != nil || c.OpVariable(op) || c.OpArg(op)
 }
 
 // DefinedBinary reports whether the operator is a known binary.
@@ -24,7 +24,7 @@ func (c *Context) DefinedBinary(op string) bool {
 	if c.isVariable(op) {
 		return false
 	}
-	return c.BinaryFn[op] != nil || value.BinaryOps[op] != nil
+	return c.BinaryFn[op] != nil || value.BinaryOps[op] != nil || c.OpVariable(op) || c.OpArg(op)
 }
 
 // DefinedUnary reports whether the operator is a known unary.
@@ -32,5 +32,5 @@ func (c *Context) DefinedUnary(op string) bool {
 	if c.isVariable(op) {
 		return false
 	}
-	return c.UnaryFn[op] != nil || value.UnaryOps[op] != nil
+	return c.UnaryFn[op] != nil || value.UnaryOps[op] != nil || c.OpVariable(op) || c.OpArg(op)
 }
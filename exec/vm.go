@@ -0,0 +1,364 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"fmt"
+	"strings"
+
+	"robpike.io/ivy/value"
+)
+
+// The bodies of user-defined ops are compiled, by the parser, to a
+// compact bytecode run by a simple stack machine. Variables are
+// resolved to local slots or global names when the op is defined.
+// Expressions the compiler does not handle are evaluated by walking
+// their trees, so the semantics are those of the tree walker; with the
+// "vm" debug flag set, every call is checked against it.
+//
+// Calls from one compiled op to another run in the same machine,
+// without recursion in Go.
+
+// Opcode identifies an instruction.
+type Opcode uint8
+
+const (
+	OpConst       Opcode = iota // Push Consts[Arg].
+	OpLocal                     // Push local variable Arg.
+	OpGlobal                    // Push global variable Names[Arg].
+	OpStoreLocal                // Assign the top value to local variable Arg, marking it an Assignment.
+	OpStoreGlobal               // Assign the top value to global variable Names[Arg], marking it an Assignment.
+	OpInner                     // Replace the top value by its Inner value.
+	OpScalar                    // Check that the top value is a scalar, as a vector element must be.
+	OpVector                    // Replace the top Arg values, the first element on top, by a vector.
+	OpUnary                     // Replace the top value by the result of the unary op named Names[Arg].
+	OpBinary                    // Replace the top two values, left on top, by the result of the binary op named Names[Arg].
+	OpEvalUnary                 // Like OpUnary, for any operator, such as a reduction, evaluated by Context.EvalUnary.
+	OpEvalBinary                // Like OpBinary, for any operator, such as a product, evaluated by Context.EvalBinary.
+	OpExpr                      // Push the value of Exprs[Arg], found by walking its tree.
+	OpSet                       // Pop the value of a statement, which becomes the result of the op.
+	OpJump                      // Jump to Arg.
+	OpJumpFalse                 // Pop a condition and jump to Arg if it is false.
+	OpIter                      // Start the for loop Exprs[Arg].
+	OpNext                      // Assign the loop variable of the innermost for loop, or end the loop and jump to Arg.
+	OpEndIter                   // End the innermost for loop.
+	OpReturn                    // Return the top value.
+	OpEnd                       // Return the result of the last statement.
+)
+
+var opcodeNames = [...]string{
+	OpConst:       "const",
+	OpLocal:       "local",
+	OpGlobal:      "global",
+	OpStoreLocal:  "storelocal",
+	OpStoreGlobal: "storeglobal",
+	OpInner:       "inner",
+	OpScalar:      "scalar",
+	OpVector:      "vector",
+	OpUnary:       "unary",
+	OpBinary:      "binary",
+	OpEvalUnary:   "evalunary",
+	OpEvalBinary:  "evalbinary",
+	OpExpr:        "expr",
+	OpSet:         "set",
+	OpJump:        "jump",
+	OpJumpFalse:   "jumpfalse",
+	OpIter:        "iter",
+	OpNext:        "next",
+	OpEndIter:     "enditer",
+	OpReturn:      "return",
+	OpEnd:         "end",
+}
+
+func (op Opcode) String() string {
+	return opcodeNames[op]
+}
+
+// Instr is an instruction: an opcode and its argument.
+type Instr struct {
+	Op  Opcode
+	Arg int
+}
+
+// Code is the compiled body of an op.
+type Code struct {
+	Instrs []Instr
+	Consts []value.Value
+	Names  []string     // Names of globals and ops.
+	Exprs  []value.Expr // Expressions evaluated by the tree walker, and for loops.
+}
+
+// Assignment is an implementation of Value that is created as the result of an assignment.
+// It can be type-asserted to discover whether the returned value was created by assignment,
+// such as is done in the interpreter to avoid printing the results of assignment expressions.
+type Assignment struct {
+	value.Value
+}
+
+// Listing returns the instructions of fn's code, one per line,
+// for debugging.
+func (fn *Function) Listing() string {
+	var b strings.Builder
+	code := fn.Code
+	for pc, in := range code.Instrs {
+		fmt.Fprintf(&b, "%d\t%s", pc, in.Op)
+		switch in.Op {
+		case OpConst:
+			fmt.Fprintf(&b, "\t%s", code.Consts[in.Arg].ProgString())
+		case OpLocal, OpStoreLocal:
+			fmt.Fprintf(&b, "\t%s", fn.Locals[in.Arg-1])
+		case OpGlobal, OpStoreGlobal, OpUnary, OpBinary, OpEvalUnary, OpEvalBinary:
+			fmt.Fprintf(&b, "\t%s", code.Names[in.Arg])
+		case OpExpr, OpIter:
+			fmt.Fprintf(&b, "\t%s", strings.ReplaceAll(code.Exprs[in.Arg].ProgString(), "\n", "; "))
+		case OpVector, OpJump, OpJumpFalse, OpNext:
+			fmt.Fprintf(&b, "\t%d", in.Arg)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// evalBody evaluates the body of fn, whose frame has been pushed and
+// whose arguments have been assigned.
+func (c *Context) evalBody(fn *Function) value.Value {
+	c.checkDeadline()
+	if fn.Code == nil {
+		return value.EvalFunctionBody(c, fn.Name, fn.Body)
+	}
+	switch {
+	case c.checking == walking:
+		return value.EvalFunctionBody(c, fn.Name, fn.Body)
+	case c.checking == running || !c.config.Debug("vm"):
+		return c.run(fn)
+	}
+	if !c.checkable(fn, make(map[*Function]bool)) {
+		// Running it twice would do its effects twice.
+		return c.run(fn)
+	}
+	// Run it both ways, starting from the same frame, and compare.
+	// The ops it calls run the same way as it does.
+	defer func() { c.checking = 0 }()
+	frame := c.stack[len(c.stack)-len(fn.Locals):]
+	saved := append([]value.Value(nil), frame...)
+	c.checking = running
+	v := c.run(fn)
+	copy(frame, saved)
+	c.checking = walking
+	w := value.EvalFunctionBody(c, fn.Name, fn.Body)
+	if !c.sameValue(v, w) {
+		value.Errorf("vm: %s gives %s; tree walker gives %s", fn.Name, c.describe(v), c.describe(w))
+	}
+	return v
+}
+
+// checkable reports whether fn and the ops it calls do nothing but
+// compute a value, so fn can be run twice to check the VM against the
+// tree walker without changing what it does. The ops in seen are known
+// to be checkable if the others are.
+func (c *Context) checkable(fn *Function, seen map[*Function]bool) bool {
+	if fn.Effects {
+		return false
+	}
+	if seen[fn] {
+		return true
+	}
+	seen[fn] = true
+	for _, ref := range fn.Calls {
+		callee := c.UnaryFn[ref.Name]
+		if ref.IsBinary {
+			callee = c.BinaryFn[ref.Name]
+		}
+		if callee == nil || callee.Body == nil || !c.checkable(callee, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// What is being done while checking the VM against the tree walker.
+const (
+	running = 1 + iota
+	walking
+)
+
+// sameValue reports whether v and w print the same and have the same type.
+func (c *Context) sameValue(v, w value.Value) bool {
+	if v == nil || w == nil {
+		return v == w
+	}
+	return fmt.Sprintf("%T", v) == fmt.Sprintf("%T", w) && v.Sprint(c.config) == w.Sprint(c.config)
+}
+
+func (c *Context) describe(v value.Value) string {
+	if v == nil {
+		return "no value"
+	}
+	return v.Sprint(c.config)
+}
+
+// vmFrame holds the state of a compiled op that is calling another.
+type vmFrame struct {
+	fn       *Function
+	pc       int
+	result   value.Value
+	iterBase int
+}
+
+// call pushes the frame for a call of fn, which is known to be compiled.
+func (c *Context) call(fn *Function) {
+	if uint(len(c.frameSizes)) >= c.config.MaxStack() {
+		value.Errorf("stack overflow calling %q", fn.Name)
+	}
+	c.checkDeadline()
+	c.push(fn)
+}
+
+// run runs the code of fn, whose frame has been pushed and whose
+// arguments have been assigned, and returns the result.
+func (c *Context) run(fn *Function) value.Value {
+	// Pop the frames of any calls abandoned by an error.
+	depth := len(c.frameSizes)
+	defer func() {
+		for len(c.frameSizes) > depth {
+			c.pop()
+		}
+	}()
+	var (
+		code     = fn.Code
+		pc       int
+		result   value.Value
+		stack    []value.Value
+		iters    []func() bool
+		iterBase int
+		frames   []vmFrame
+	)
+	for {
+		in := code.Instrs[pc]
+		pc++
+		switch in.Op {
+		case OpConst:
+			stack = append(stack, code.Consts[in.Arg])
+		case OpLocal:
+			v := c.Local(in.Arg)
+			if v == nil {
+				value.Errorf("undefined local variable %q", fn.Locals[in.Arg-1])
+			}
+			stack = append(stack, v)
+		case OpGlobal:
+			v := c.Global(code.Names[in.Arg])
+			if v == nil {
+				value.Errorf("undefined global variable %q", code.Names[in.Arg])
+			}
+			stack = append(stack, v)
+		case OpStoreLocal:
+			v := stack[len(stack)-1]
+			c.AssignLocal(in.Arg, v)
+			stack[len(stack)-1] = Assignment{Value: v}
+		case OpStoreGlobal:
+			v := stack[len(stack)-1]
+			c.AssignGlobal(code.Names[in.Arg], v)
+			stack[len(stack)-1] = Assignment{Value: v}
+		case OpInner:
+			stack[len(stack)-1] = stack[len(stack)-1].Inner()
+		case OpScalar:
+			if v := stack[len(stack)-1]; v.Rank() != 0 {
+				value.Errorf("vector element must be scalar; have %s", v)
+			}
+		case OpVector:
+			n := len(stack) - in.Arg
+			v := make([]value.Value, in.Arg)
+			for i := range v {
+				v[i] = stack[len(stack)-1-i]
+			}
+			stack = append(stack[:n], value.NewVector(v))
+		case OpUnary:
+			top := len(stack) - 1
+			op := code.Names[in.Arg]
+			u := c.Unary(op)
+			if u == nil {
+				value.Errorf("unary %q not implemented", op)
+			}
+			if f, ok := u.(*Function); ok && f.Code != nil {
+				c.call(f)
+				c.AssignLocal(1, stack[top])
+				stack = stack[:top]
+				frames = append(frames, vmFrame{fn, pc, result, iterBase})
+				fn, code, pc, result, iterBase = f, f.Code, 0, nil, len(iters)
+				continue
+			}
+			stack[top] = u.EvalUnary(c, stack[top])
+		case OpBinary:
+			top := len(stack) - 1
+			op := code.Names[in.Arg]
+			b := c.Binary(op)
+			if b == nil {
+				value.Errorf("binary %q not implemented", op)
+			}
+			if f, ok := b.(*Function); ok && f.Code != nil {
+				c.call(f)
+				c.AssignLocal(1, stack[top])
+				c.AssignLocal(2, stack[top-1])
+				stack = stack[:top-1]
+				frames = append(frames, vmFrame{fn, pc, result, iterBase})
+				fn, code, pc, result, iterBase = f, f.Code, 0, nil, len(iters)
+				continue
+			}
+			stack[top-1] = b.EvalBinary(c, stack[top], stack[top-1])
+			stack = stack[:top]
+		case OpEvalUnary:
+			top := len(stack) - 1
+			stack[top] = c.EvalUnary(code.Names[in.Arg], stack[top])
+		case OpEvalBinary:
+			top := len(stack) - 1
+			stack[top-1] = c.EvalBinary(stack[top], code.Names[in.Arg], stack[top-1])
+			stack = stack[:top]
+		case OpExpr:
+			stack = append(stack, code.Exprs[in.Arg].Eval(c))
+		case OpSet:
+			result = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		case OpJump:
+			// Every loop jumps back to its start.
+			c.checkDeadline()
+			pc = in.Arg
+		case OpJumpFalse:
+			cond := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !value.IsTrue(fn.Name, cond) {
+				pc = in.Arg
+			}
+		case OpIter:
+			iters = append(iters, code.Exprs[in.Arg].(value.Loop).Iterate(c))
+		case OpNext:
+			if !iters[len(iters)-1]() {
+				iters = iters[:len(iters)-1]
+				pc = in.Arg
+			}
+		case OpEndIter:
+			iters = iters[:len(iters)-1]
+		case OpReturn, OpEnd:
+			v := result
+			if in.Op == OpReturn {
+				v = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			if len(frames) == 0 {
+				return v
+			}
+			if v == nil {
+				value.Errorf("no value returned by %q", fn.Name)
+			}
+			c.pop()
+			iters = iters[:iterBase]
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			fn, code, pc, result, iterBase = f.fn, f.fn.Code, f.pc, f.result, f.iterBase
+			stack = append(stack, v)
+		default:
+			value.Errorf("vm: unknown opcode %d", in.Op)
+		}
+	}
+}
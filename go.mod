@@ -8,6 +8,7 @@ require (
 	github.com/apache/arrow/go/v10 v10.0.0-20221021053532-2f627c213fc3
 	github.com/chzyer/readline v1.5.1
 	github.com/glycerine/vprint v0.0.0-20200730000117-76cea49a68ea
+	google.golang.org/grpc v1.49.0
 )
 
 require (
@@ -35,6 +36,5 @@ require (
 	golang.org/x/tools v0.3.0 // indirect
 	golang.org/x/xerrors v0.0.0-20220609144429-65e65417b02f // indirect
 	google.golang.org/genproto v0.0.0-20220503193339-ba3ae3f07e29 // indirect
-	google.golang.org/grpc v1.49.0 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
 )
@@ -9,9 +9,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"runtime/pprof"
 	"strings"
+	"time"
 
 	"github.com/apache/arrow/go/v10/arrow"
 	"github.com/apache/arrow/go/v10/arrow/array"
@@ -22,6 +24,7 @@ import (
 	"robpike.io/ivy/parse"
 	"robpike.io/ivy/run"
 	"robpike.io/ivy/scan"
+	"robpike.io/ivy/server"
 	"robpike.io/ivy/value"
 )
 
@@ -37,7 +40,12 @@ var (
 	origin          = flag.Int("origin", 1, "set index origin to `n` (must be >=0)")
 	prompt          = flag.String("prompt", "", "command `prompt`")
 	debugFlag       = flag.String("debug", "", "comma-separated `names` of debug settings to enable")
+	jsonOutput      = flag.Bool("json", false, "print results as JSON")
 	parquet         = flag.String("parquet", "", "execute with arrow table")
+	serve           = flag.String("serve", "", "serve the HTTP API on network `address` and do nothing else")
+	idle            = flag.Duration("idle", 30*time.Minute, "with -serve, discard sessions unused for `duration`; 0 means never")
+	evalTimeout     = flag.Duration("evaltimeout", server.DefaultEvalTimeout, "with -serve, stop evaluations running longer than `duration`; 0 means never")
+	maxSessions     = flag.Int("maxsessions", server.DefaultMaxSessions, "with -serve, allow at most `n` sessions; 0 means no limit")
 )
 
 var (
@@ -62,22 +70,18 @@ func main() {
 		*format = "%.12g"
 	}
 
-	conf.SetFormat(*format)
-	conf.SetMaxBits(*maxbits)
-	conf.SetMaxDigits(*maxdigits)
-	conf.SetMaxStack(*maxstack)
-	conf.SetOrigin(*origin)
-	conf.SetPrompt(*prompt)
+	setConfig(&conf)
 
-	if len(*debugFlag) > 0 {
-		for _, debug := range strings.Split(*debugFlag, ",") {
-			if !conf.SetDebug(debug, true) {
-				fmt.Fprintf(os.Stderr, "ivy: unknown debug flag %q\n", debug)
-				os.Exit(2)
-			}
-		}
+	if *serve != "" {
+		srv := server.New(setConfig, *idle)
+		srv.EvalTimeout = *evalTimeout
+		srv.MaxSessions = *maxSessions
+		fmt.Fprintln(os.Stderr, http.ListenAndServe(*serve, srv))
+		os.Exit(1)
 	}
 
+	conf.SetJSONOutput(*jsonOutput)
+
 	context = exec.NewContext(&conf)
 
 	if *file != "" {
@@ -132,6 +136,25 @@ func main() {
 	}
 }
 
+// setConfig applies the settings from the command-line flags to the configuration.
+func setConfig(conf *config.Config) {
+	conf.SetFormat(*format)
+	conf.SetMaxBits(*maxbits)
+	conf.SetMaxDigits(*maxdigits)
+	conf.SetMaxStack(*maxstack)
+	conf.SetOrigin(*origin)
+	conf.SetPrompt(*prompt)
+
+	if len(*debugFlag) > 0 {
+		for _, debug := range strings.Split(*debugFlag, ",") {
+			if !conf.SetDebug(debug, true) {
+				fmt.Fprintf(os.Stderr, "ivy: unknown debug flag %q\n", debug)
+				os.Exit(2)
+			}
+		}
+	}
+}
+
 // TWG(twg) testing column needes to be released
 func NewArrowIntColumn(v []int64, pool memory.Allocator) *arrow.Column {
 	schema := arrow.NewSchema(
@@ -174,4 +174,5 @@ func reset() {
 	testConf.SetPrompt("")
 	testConf.SetBase(0, 0)
 	testConf.SetRandomSeed(0)
+	testConf.SetFloat64(false)
 }
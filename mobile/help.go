@@ -76,6 +76,10 @@ and inexact.
 <p>Unary operators
 <pre>Name              APL   Ivy     Meaning
 Roll              ?B    ?       One integer selected randomly from the first B integers
+Uniform                 uniform Array of shape B of floats chosen randomly from [0, 1)
+Normal                  normal  Array of shape B of normally distributed floats, mean 0 and standard deviation 1
+Exponential             expo    Array of shape B of exponentially distributed floats, mean 1
+Shuffle                 shuffle The items of B in random order
 Ceiling           ⌈B    ceil    Least integer greater than or equal to B
 Floor             ⌊B    floor   Greatest integer less than or equal to B
 Shape             ⍴B    rho     Number of components in each dimension of B
@@ -88,16 +92,26 @@ Identity          +B    +       No change to B
 Signum            ×B    sgn     ¯1 if B&lt;0; 0 if B=0; 1 if B&gt;0
 Reciprocal        ÷B    /       1 divided by B
 Ravel             ,B    ,       Reshapes B into a vector
-Matrix inverse    ⌹B            Inverse of matrix B
+Matrix inverse    ⌹B    inv     Inverse of matrix B; pseudo-inverse if B has more rows than columns
 Pi times          ○B            Multiply by π
 Logarithm         ⍟B    log     Natural logarithm of B
 Reversal          ⌽B    rot     Reverse elements of B along last axis
 Reversal          ⊖B    flip    Reverse elements of B along first axis
 Grade up          ⍋B    up      Indices of B which will arrange B in ascending order
 Grade down        ⍒B    down    Indices of B which will arrange B in descending order
+Unique            ∪B    unique  Elements of B without repeats, in order of first occurrence
+Count                   count   Matrix of the distinct elements of B and the number of times each occurs
+Mean                    mean    Arithmetic mean of B; of each column if B is a matrix
+Median                  median  Middle value of B, or the mean of the two middle values
+Mode                    mode    Most frequent element of B, the first to occur if there is a tie
+Variance                var     Sample variance of B, dividing by one less than the count
+Standard deviation      sdev    Square root of the sample variance of B
 Execute           ⍎B    ivy     Execute an APL (ivy) expression
 Monadic format    ⍕B    text    A character representation of B
 Monadic transpose ⍉B    transp  Reverse the axes of B
+Enclose           ⊂B    box     A scalar holding B, so it can be an element of a vector
+Disclose          ⊃B    unbox   The contents of box B; boxes in B are mixed into one array
+Depth             ≡B    depth   Levels of nesting of boxes in B; 0 for a simple scalar
 Factorial         !B    !       Product of integers 1 to B
 Bitwise not             ^       Bitwise complement of B (integer only)
 Square root       B⋆.5  sqrt    Square root of B.
@@ -117,6 +131,7 @@ Rotation by 90°         j       Multiplication by sqrt(-1)
 Real part               real    Real component of the value
 Imaginary part          imag    Imaginary component of the value
 Phase                   phase   Phase of the value in the complex plane (-π to π)
+Raise error             raise   Fail with the text of B as the error message
 </pre>
 <p>Binary operators
 <pre>Name                  APL   Ivy     Meaning
@@ -133,7 +148,29 @@ Circle                A○B           Trigonometric functions of B selected by A
                             cos     cos(B); ivy uses traditional name.
                             tan     tan(B); ivy uses traditional name.
 Deal                  A?B   ?       A distinct integers selected randomly from the first B integers
+Uniform                     uniform Array of shape B of floats chosen randomly from [0, A), or from
+                                    [A[1], A[2]) if A has two elements
+Normal                      normal  Array of shape B of normally distributed floats with mean A[1]
+                                    and standard deviation A[2], or 1 if A has one element
+Exponential                 expo    Array of shape B of exponentially distributed floats with rate A
+Poisson                     poisson Array of shape B of integers with a Poisson distribution of mean A
+Binomial                    binomial  Array of shape B of the numbers of successes in A[1] trials
+                                    each with probability A[2]
+Weighted sample             sample  Array of shape B of indices of A chosen randomly, with replacement,
+                                    with probability proportional to the weights A
 Membership            A∈B   in      1 for elements of A present in B; 0 where not.
+Union                 A∪B   union   A followed by the elements of B not in A
+Intersection          A∩B   intersect  Elements of A that are also in B
+Without               A~B   without Elements of A that are not in B
+Interval index        A⍸B   bin     Index of the interval between the ascending edges A holding each
+                                    element of B: the number of edges ≤ B, less 1 if origin is 0
+Quantile                    quantile  Value below which fraction A of the data B lies,
+                                    interpolating between elements; of each column of a matrix
+Percentile                  percentile  Value below which A percent of the data B lies
+Covariance                  cov     Sample covariance of A and B
+Correlation                 corr    Correlation coefficient of A and B
+Windows                     window  Matrix whose columns are the windows of A elements of B;
+                                    median 3 window B gives the rolling median of B
 Maximum               A⌈B   max     The greater value of A or B
 Minimum               A⌊B   min     The smaller value of A or B
 Reshape               A⍴B   rho     Array of shape A with data B
@@ -151,7 +188,8 @@ Compression           A/B   sel     Select elements in B corresponding to ones i
                                     In ivy: abs(A) gives count, A &lt;= 0 inserts zero
 Index of              A⍳B   iota    The location (index) of B in A; 1+⌈/⍳⍴A if not found
                                     In ivy: origin-1 if not found (i.e. 0 if one-indexed)
-Matrix divide         A⌹B           Solution to system of linear equations Ax = B
+Matrix divide         A⌹B   mdiv    Solution to system of linear equations Ax = B
+                                    In ivy: least-squares solution if A has more rows than columns
 Rotation              A⌽B   rot     The elements of B are rotated A positions left
 Rotation              A⊖B   flip    The elements of B are rotated A positions along the first axis
 Logarithm             A⍟B   log     Logarithm of B to base A
@@ -183,12 +221,19 @@ Complex construction        j       The complex number A+Bi
 <p>Operators and axis indicator
 <pre>Name                APL  Ivy  APL Example  Ivy Example  Meaning (of example)
 Reduce (last axis)  /    /    +/B          +/B          Sum across B
-Reduce (first axis) ⌿         +⌿B                       Sum down B
+N-wise reduce       /    /    N+/B         N +/ B       Sums of windows of N elements of B
+                                                    (each window is taken backwards if N is negative)
+Reduce (first axis) ⌿    /%   +⌿B          +/%B         Sum down B
 Scan (last axis)    \    \    +\B          +\B          Running sum across B
-Scan (first axis)   ⍀         +⍀B                       Running sum down B
+Scan (first axis)   ⍀    \%   +⍀B          +\%B         Running sum down B
 Inner product       .    .    A+.×B        A +.* B      Matrix product of A and B
 Outer product       ∘.   o.   A∘.×B        A o.* B      Outer product of A and B
                                                     (lower case o; may need preceding space)
+Each                ¨    @    f¨B          f@ B         Apply f to each item of B
+                                                    (A f@ B applies f to items of A and B)
+Power               ⍣    power f⍣N B       N f power B  Apply f to B N times
+                                                    (f power B applies f until the result stops changing;
+                                                    (g) f power B until new g previous is true)
 </pre>
 <p>Type-converting operations
 <pre>Name              APL   Ivy     Meaning
@@ -197,6 +242,10 @@ Char                    char B  The character with integer Unicode value B
 Float                   float B The floating-point representation of B;
                                 for complex numbers, the result is
                                 (float A)j(float B)
+JSON                    json B  The JSON representation of B, as a string
+                                (see &quot;JSON&quot; below)
+From JSON               fromjson B
+                                The value represented by the JSON string B
 </pre>
 <h3 id="hdr-Pre_defined_constants">Pre-defined constants</h3>
 <p>The constants e (base of natural logarithms) and pi (π) are pre-defined to high
@@ -220,6 +269,35 @@ spaces between them.
 legal but arithmetic is not, and chars cannot be converted automatically into other
 singleton values (ints, floats, and so on). The unary operators char and code
 enable transcoding between integer and char values.
+<h3 id="hdr-Boxes">Boxes</h3>
+<p>The elements of vectors and matrices are scalars. To hold values of other
+shapes, such as a list of names of differing lengths, enclose them in a box,
+which is a scalar. Boxes print with a frame around their contents.
+<pre>names = (box &apos;ann&apos;) (box &apos;bob&apos;) (box &apos;carol&apos;)
+rho names
+result: 3
+unbox names[3]
+result: carol
+</pre>
+<p>Elementwise operators apply to the contents of boxes and box the result, so
+(box 1 2) + 10 is (box 11 12). Unbox of a vector or matrix of boxes mixes the
+contents into an array with an extra axis, padding shorter items with zeros
+(blanks for chars).
+<h3 id="hdr-JSON">JSON</h3>
+<p>The unary operators json and fromjson convert between values and JSON text.
+Integers become JSON numbers, floats become JSON numbers with a decimal point
+or exponent, and a char or vector of chars becomes a JSON string. Other vectors
+become JSON arrays. Rationals, complex numbers, and matrices become objects:
+<pre>json 1/3
+result: {&quot;rat&quot;: &quot;1/3&quot;}
+json 1j2
+result: {&quot;re&quot;: 1, &quot;im&quot;: 2}
+json 2 3 rho iota 6
+result: {&quot;shape&quot;: [2, 3], &quot;data&quot;: [[1, 2, 3], [4, 5, 6]]}
+</pre>
+<p>When converting from JSON, true and false become 1 and 0, null is an error,
+and nested arrays of equal length become a matrix. The -json flag prints each
+result as JSON on a line of its own, for use by other programs.
 <h3 id="hdr-User_defined_operators">User-defined operators</h3>
 <p>Users can define unary and binary operators, which then behave just like
 built-in operators. Both a unary and a binary operator may be defined for the
@@ -272,6 +350,37 @@ result: 2 3 5 7 11 13 17 19 23 29 31 37 41 43 47
 1562 gcd !11
 result: 22
 </pre>
+<p>The lines of a multiline operator may include loops, which repeat the
+lines between the loop&apos;s first line and a line holding &quot;:end&quot;. A &quot;:while&quot;
+loop runs while its scalar condition is non-zero; a &quot;:for&quot; loop assigns each
+item of a vector, or each row of a matrix, to its variable in turn. Within a
+loop, &quot;:break&quot; leaves the loop and &quot;:continue&quot; starts its next iteration.
+Either may be the right operand of &quot;:&quot;, and a &quot;:&quot; return inside a loop
+returns from the operator.
+<p>Example: factorial with a loop (unary):
+<pre>op fac n =
+	r = 1
+	:while n &gt; 1
+		r = r*n
+		n = n-1
+	:end
+	r
+
+fac 10
+result: 3628800
+</pre>
+<p>Example: sum of the elements up to the first negative one (unary):
+<pre>op total v =
+	t = 0
+	:for x in v
+		x &lt; 0: :break
+		t = t+x
+	:end
+	t
+
+total 1 2 3 -4 5
+result: 6
+</pre>
 <p>On mobile platforms only, due to I/O restrictions, user-defined operators
 must be presented on a single line. Use semicolons to separate expressions:
 <pre>op a gcd b = a == b: a; a &gt; b: b gcd a-b; a gcd b-a
@@ -298,6 +407,99 @@ op save x =
 total last
 result: 12 3
 </pre>
+<h3 id="hdr-Anonymous_operators">Anonymous operators</h3>
+<p>An operator may also be written in place, without a name, as a body in braces.
+Within the body, x is the argument of a unary operator and y is absent; for a
+binary operator, x is the left argument and y the right. As with &apos;op&apos;, the
+body may hold several expressions separated by semicolons, including &quot;:&quot;
+conditional returns. An anonymous operator can be used wherever an operator
+can, including in reductions, scans, products and with each:
+<pre>{x*2} 1 2 3
+result: 2 4 6
+{x max y}/ 3 1 4 1 5
+result: 5
+1 2 3 +.{x*y} 4 5 6
+result: 32
+</pre>
+<p>An anonymous operator that is not applied is a value, which can be assigned to
+a variable. The variable may then be used as an operator, and may refer to
+itself:
+<pre>fact = {x &lt;= 1: 1; x * fact x-1}
+fact 5
+result: 120
+</pre>
+<p>Within a user-defined operator, an anonymous operator captures the values of
+the local variables it reads, as they are when it is evaluated, so it can be
+returned as the result:
+<pre>op scale n = {x*n}
+triple = scale 3
+triple 1 2 3
+result: 3 6 9
+</pre>
+<h3 id="hdr-Higher_order_operators">Higher-order operators</h3>
+<p>A user-defined operator may take an operator as an operand, written in
+parentheses before its name in the definition. Within the body, the operand
+is used like any other operator. When the higher-order operator is applied,
+the operand, which may be built-in, user-defined or anonymous, is written
+before its name:
+<pre>op (f) twice x = f f x
+{x*2} twice 3
+result: 12
+op a (f) both b = (a f b) (b f a)
+3 - both 10
+result: -7 7
+</pre>
+<p>An operator that uses its operand in a reduction:
+<pre>op (f) fold v = f/ v
+max fold 3 1 4 1 5
+result: 5
+</pre>
+<p>Repeated application until the result stops changing:
+<pre>op (f) fix x =
+	y = f x
+	y == x: x
+	f fix y
+
+{floor x/2} fix 100
+result: 0
+</pre>
+<p>The built-in higher-order op power does this directly. With a left
+argument, it applies its operand that many times; without one, it applies
+it until the result stops changing, comparing floating-point results to
+within the last few bits of precision. If the left argument is itself an
+op, it is a condition: the operand is applied until the condition, given
+the new result as its left argument and the previous one as its right,
+is true. Power gives up with an error after about a million iterations.
+<pre>{floor x/2} power 100
+result: 0
+3 sqrt power 256
+result: 2
+{(x + 2/x)/2} power float 1.5
+result: 1.41421356237
+({x &gt; 100}) {x*2} power 1
+result: 128
+</pre>
+<h3 id="hdr-Trapping_errors">Trapping errors</h3>
+<p>An expression that may fail can be trapped with try, written
+<pre>try expression : fallback
+</pre>
+<p>If the expression succeeds, its value is the result. If it fails, such as
+by dividing by zero or with a length mismatch, the fallback is evaluated
+instead. The &quot;:&quot; separates the two and binds more loosely than any operator,
+so parenthesize a try within a larger expression. If the fallback is an
+anonymous operator, it is applied to the error message as a char vector.
+The raise operator fails with an error of one&apos;s own.
+<pre>try 1 / 0 : 0
+result: 0
+try 1 2 + 3 4 5 : {&apos;caught: &apos;, x}
+result: caught: length mismatch: 2 3
+op check n =
+	n &lt; 0: raise &apos;negative: &apos;, text n
+	sqrt n
+
+try check -4 : {x}
+result: negative: -4
+</pre>
 <h3 id="hdr-Special_commands">Special commands</h3>
 <p>Ivy accepts a number of special commands, introduced by a right paren
 at the beginning of the line. Most report the current value if a new value
@@ -318,9 +520,24 @@ base 10 and must be non-negative on input.
 ) debug name 0|1
 	Toggle or set the named debugging flag. With no argument, lists
 	the settings.
+	User-defined ops are compiled to bytecode when defined. The vm
+	flag prints the bytecode of each op as it is defined and checks
+	the result of each call against that of walking the op&apos;s parse
+	tree, which runs the op twice. Ops that may do more than compute
+	a value, such as by assigning to a global, are run only once and
+	not checked.
 ) demo
 	Run a line-by-line interactive demo. On mobile platforms,
 	use the Demo menu option instead.
+) float64 0
+	If 1, floating-point values are native 64-bit floats with IEEE
+	semantics, so for instance division of a float by zero, log 0
+	and atanh 1 give infinities. Arithmetic on them, and on Arrow
+	floating-point columns, runs at hardware speed. Exact values
+	remain exact, so 1/0 is still an error, and results that are not
+	real, such as sqrt -1 and log -1, are complex, as they are
+	otherwise, rather than NaN. If 0, the default, floating-point
+	values have the precision set by ) prec.
 ) format &quot;&quot;
 	Set the format for printing values. If empty, the output is printed
 	using the output base. If non-empty, the format determines the
@@ -359,7 +576,9 @@ base 10 and must be non-negative on input.
 	&quot;save.ivy&quot;.
 	(Unimplemented on mobile.)
 ) seed 0
-	Set the seed for the ? operator.
+	Set the seed for the ? operator and the random distributions.
+	A given seed produces the same values however much of the
+	computation is done in parallel.
 </pre>
 </body></html>
 `
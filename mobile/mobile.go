@@ -7,8 +7,8 @@
 // It is designed to work well with the gomobile tool by exposing
 // only primitive types. It's also handy for testing.
 //
-// TODO: This package (and ivy itself) has global state, so only
-// one execution stream (Eval or Demo) can be active at a time.
+// Each Session is an independent interpreter with its own configuration
+// and variables. The package-level functions operate on a default session.
 package mobile
 
 //go:generate sh -c "go run help_gen.go >help.go"
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"robpike.io/ivy/config"
 	"robpike.io/ivy/exec"
@@ -26,13 +27,22 @@ import (
 	"robpike.io/ivy/value"
 )
 
-var (
+// defaultSession is the session used by the package-level functions.
+var defaultSession = NewSession()
+
+// Session is an independent ivy interpreter. It is safe to use a Session
+// from multiple goroutines; evaluations are serialized.
+type Session struct {
+	mu      sync.Mutex
 	conf    config.Config
 	context value.Context
-)
+}
 
-func init() {
-	Reset()
+// NewSession returns a new Session in its initial state.
+func NewSession() *Session {
+	s := new(Session)
+	s.reset()
+	return s
 }
 
 // On mobile platforms, the output gets turned into HTML.
@@ -42,13 +52,21 @@ func init() {
 // backslashes are trouble. Here is the hacky fix.
 var escaper = strings.NewReplacer(" ", "\u00A0", "\t", "    ", "\\", "&#92;")
 
-// Eval evaluates the input string and returns its output.
+// Eval evaluates the input string in the default session and returns its output.
 // The output is HTML-safe, suitable for mobile platforms.
 func Eval(expr string) (string, error) {
+	return defaultSession.Eval(expr)
+}
+
+// Eval evaluates the input string and returns its output.
+// The output is HTML-safe, suitable for mobile platforms.
+func (s *Session) Eval(expr string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	stdout := new(bytes.Buffer)
 	stderr := new(bytes.Buffer)
-	conf.SetErrOutput(stderr)
-	run.Ivy(context, expr, stdout, stderr)
+	s.conf.SetErrOutput(stderr)
+	run.Ivy(s.context, expr, stdout, stderr)
 	result := escaper.Replace(stdout.String())
 	if stderr.Len() > 0 {
 		return result, fmt.Errorf(stderr.String())
@@ -58,15 +76,22 @@ func Eval(expr string) (string, error) {
 
 // Demo represents a running line-by-line demonstration.
 type Demo struct {
+	session *Session
 	scanner *bufio.Scanner
 }
 
 // NewDemo returns a new Demo that will scan the input text line by line.
+// It resets the default session.
 func NewDemo(input string) *Demo {
-	// TODO: The state being reset should be local to the demo.
-	// but that's not worth doing until ivy itself has no global state.
-	Reset()
+	return defaultSession.Demo(input)
+}
+
+// Demo resets the session and returns a new Demo that will scan the
+// input text line by line, evaluating it in the session.
+func (s *Session) Demo(input string) *Demo {
+	s.Reset()
 	return &Demo{
+		session: s,
 		scanner: bufio.NewScanner(strings.NewReader(input)),
 	}
 }
@@ -80,23 +105,40 @@ func (d *Demo) Next() (result string, err error) {
 		}
 		return "", io.EOF
 	}
-	return Eval(d.scanner.Text())
+	return d.session.Eval(d.scanner.Text())
 }
 
-// Reset clears all state to the initial value.
+// Reset clears all state of the default session to the initial value.
 func Reset() {
-	conf.SetFormat("")
-	conf.SetMaxBits(1e9)
-	conf.SetMaxDigits(1e4)
-	conf.SetOrigin(1)
-	conf.SetPrompt("")
-	conf.SetBase(0, 0)
-	conf.SetRandomSeed(0)
-	conf.SetMobile(true)
-	context = exec.NewContext(&conf)
+	defaultSession.Reset()
+}
+
+// Reset clears all state to the initial value.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+}
+
+// reset clears all state. The caller must hold s.mu.
+func (s *Session) reset() {
+	s.conf.SetFormat("")
+	s.conf.SetMaxBits(1e9)
+	s.conf.SetMaxDigits(1e4)
+	s.conf.SetOrigin(1)
+	s.conf.SetPrompt("")
+	s.conf.SetBase(0, 0)
+	s.conf.SetRandomSeed(0)
+	s.conf.SetMobile(true)
+	s.context = exec.NewContext(&s.conf)
 }
 
 // Help returns the help page formatted in HTML.
 func Help() string {
 	return help
 }
+
+// Help returns the help page formatted in HTML.
+func (s *Session) Help() string {
+	return help
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -44,7 +45,7 @@ func TestEvalError(t *testing.T) {
 		input string
 		error string
 	}{
-		{"'x", "unterminated character constant"},
+		{"'x", "unterminated quoted string"},
 		{"1/0", "zero denominator in rational"},
 		{"1 / 0", "division by zero"},
 	}
@@ -61,6 +62,69 @@ func TestEvalError(t *testing.T) {
 	}
 }
 
+func TestSessions(t *testing.T) {
+	s1 := NewSession()
+	s2 := NewSession()
+	if _, err := s1.Eval("x = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s2.Eval("x = 2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s2.Eval(")origin 0"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := s1.Eval("x, iota 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := escaper.Replace("1 1 2 3\n"); out != want {
+		t.Errorf("session 1: expected %q; got %q", want, out)
+	}
+	out, err = s2.Eval("x, iota 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := escaper.Replace("2 0 1 2\n"); out != want {
+		t.Errorf("session 2: expected %q; got %q", want, out)
+	}
+	s1.Reset()
+	if _, err := s1.Eval("x"); err == nil {
+		t.Errorf("x still defined after Reset")
+	}
+	if _, err := s2.Eval("x"); err != nil {
+		t.Errorf("Reset of session 1 affected session 2: %v", err)
+	}
+}
+
+func TestSessionConcurrency(t *testing.T) {
+	var wg sync.WaitGroup
+	s := NewSession()
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			own := NewSession()
+			for j := 0; j < 20; j++ {
+				if _, err := own.Eval("+/iota 100"); err != nil {
+					t.Error(err)
+				}
+				if _, err := s.Eval("y = +/iota 100"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	out, err := s.Eval("y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "5050\n" {
+		t.Errorf("expected %q; got %q", "5050\n", out)
+	}
+}
+
 const demoText = `# This is a demo.
 23
 iota 10
@@ -8,15 +8,15 @@ package parse
 // validity checks.
 
 import (
+	"robpike.io/ivy/exec"
 	"robpike.io/ivy/value"
 )
 
 // Assignment is an implementation of Value that is created as the result of an assignment.
 // It can be type-asserted to discover whether the returned value was created by assignment,
 // such as is done in the interpreter to avoid printing the results of assignment expressions.
-type Assignment struct {
-	value.Value
-}
+// It is defined in exec, where compiled ops make them too.
+type Assignment = exec.Assignment
 
 var scalarShape = []int{1} // The assignment shape vector for a scalar value.
 
@@ -34,8 +34,9 @@ func assignment(context value.Context, b *binary) value.Value {
 		}
 		return Assignment{Value: rhs}
 	case *index:
-		switch lhs.left.(type) {
+		switch v := lhs.left.(type) {
 		case *variableExpr:
+			unpackVariable(context, v)
 			value.IndexAssign(context, lhs, lhs.left, lhs.right, b.right, rhs)
 			return Assignment{Value: rhs}
 		case *index:
@@ -58,3 +59,18 @@ func assignment(context value.Context, b *binary) value.Value {
 	value.Errorf("cannot assign to %s", b.left.ProgString())
 	panic("not reached")
 }
+
+// unpackVariable replaces a packed vector held in the variable by the
+// equivalent Vector, because indexed assignment updates the elements
+// of the variable in place.
+func unpackVariable(context value.Context, v *variableExpr) {
+	if v.local >= 1 {
+		if p, ok := context.Local(v.local).(value.PackedVector); ok {
+			context.AssignLocal(v.local, p.ToVector())
+		}
+		return
+	}
+	if p, ok := context.Global(v.name).(value.PackedVector); ok {
+		context.AssignGlobal(v.name, p.ToVector())
+	}
+}
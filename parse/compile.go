@@ -0,0 +1,207 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+import (
+	"strings"
+
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/value"
+)
+
+// compiler compiles the body of an op to bytecode for the VM in exec.
+// Variables, operators, vectors, conditionals and loops are compiled;
+// any other expression, such as an index or an anonymous op, is kept
+// as a tree for the VM to evaluate.
+type compiler struct {
+	code  *exec.Code
+	names map[string]int
+	loops []*loopLabels // Innermost last.
+}
+
+// loopLabels records where the jumps of a loop go.
+type loopLabels struct {
+	top    int   // Where a continue goes.
+	breaks []int // Jumps to patch to where a break goes.
+}
+
+// compile sets fn.Code to the compiled form of its body. The variables
+// of the body must have been resolved by funcVars or lambdaVars.
+func compile(fn *exec.Function) {
+	c := &compiler{
+		code:  new(exec.Code),
+		names: make(map[string]int),
+	}
+	for _, stmt := range fn.Body {
+		c.stmt(stmt)
+	}
+	c.emit(exec.OpEnd, 0)
+	fn.Code = c.code
+}
+
+// emit appends an instruction and returns its address.
+func (c *compiler) emit(op exec.Opcode, arg int) int {
+	c.code.Instrs = append(c.code.Instrs, exec.Instr{Op: op, Arg: arg})
+	return len(c.code.Instrs) - 1
+}
+
+// patch sets the target of the jump at pc to the next instruction.
+func (c *compiler) patch(pc int) {
+	c.code.Instrs[pc].Arg = len(c.code.Instrs)
+}
+
+func (c *compiler) name(name string) int {
+	i, ok := c.names[name]
+	if !ok {
+		i = len(c.code.Names)
+		c.code.Names = append(c.code.Names, name)
+		c.names[name] = i
+	}
+	return i
+}
+
+func (c *compiler) constant(v value.Value) int {
+	c.code.Consts = append(c.code.Consts, v)
+	return len(c.code.Consts) - 1
+}
+
+func (c *compiler) tree(e value.Expr) int {
+	c.code.Exprs = append(c.code.Exprs, e)
+	return len(c.code.Exprs) - 1
+}
+
+// stmt compiles a statement, following value.EvalFunctionBody.
+func (c *compiler) stmt(e value.Expr) {
+	switch e := e.(type) {
+	case jump:
+		if len(c.loops) == 0 {
+			c.emit(exec.OpEnd, 0)
+			return
+		}
+		l := c.loops[len(c.loops)-1]
+		if e.isBreak {
+			l.breaks = append(l.breaks, c.emit(exec.OpJump, 0))
+		} else {
+			c.emit(exec.OpJump, l.top)
+		}
+		return
+	case *loop:
+		c.loop(e)
+		return
+	case conditional:
+		c.expr(e.left)
+		skip := c.emit(exec.OpJumpFalse, 0)
+		if j, ok := e.right.(jump); ok {
+			c.stmt(j)
+		} else {
+			c.expr(e.right)
+			c.emit(exec.OpReturn, 0)
+		}
+		c.patch(skip)
+		return
+	}
+	c.expr(e)
+	c.emit(exec.OpSet, 0)
+}
+
+// loop compiles a while or for loop.
+func (c *compiler) loop(l *loop) {
+	labels := new(loopLabels)
+	c.loops = append(c.loops, labels)
+	defer func() { c.loops = c.loops[:len(c.loops)-1] }()
+	if l.kind == "while" {
+		labels.top = len(c.code.Instrs)
+		c.expr(l.expr)
+		labels.breaks = append(labels.breaks, c.emit(exec.OpJumpFalse, 0))
+		for _, stmt := range l.body {
+			c.stmt(stmt)
+		}
+		c.emit(exec.OpJump, labels.top)
+		for _, pc := range labels.breaks {
+			c.patch(pc)
+		}
+		return
+	}
+	c.emit(exec.OpIter, c.tree(l))
+	labels.top = c.emit(exec.OpNext, 0)
+	for _, stmt := range l.body {
+		c.stmt(stmt)
+	}
+	c.emit(exec.OpJump, labels.top)
+	// A break ends the loop here; running out of values has already ended it.
+	for _, pc := range labels.breaks {
+		c.patch(pc)
+	}
+	c.emit(exec.OpEndIter, 0)
+	c.patch(labels.top)
+}
+
+// expr compiles an expression, which leaves its value on the stack.
+// The order of evaluation is that of the tree walker.
+func (c *compiler) expr(e value.Expr) {
+	switch e := e.(type) {
+	case *variableExpr:
+		if e.local >= 1 {
+			c.emit(exec.OpLocal, e.local)
+		} else {
+			c.emit(exec.OpGlobal, c.name(e.name))
+		}
+		return
+	case sliceExpr:
+		for i := len(e) - 1; i >= 0; i-- {
+			c.expr(e[i])
+			c.emit(exec.OpScalar, 0)
+		}
+		c.emit(exec.OpVector, len(e))
+		return
+	case *unary:
+		// Fused chains are left to the tree walker.
+		if e.fns == nil && fusionOf(e) == nil {
+			c.innerExpr(e.right)
+			c.emit(applyOp(e.op, exec.OpUnary, exec.OpEvalUnary), c.name(e.op))
+			return
+		}
+	case *binary:
+		if e.op == "=" {
+			if v, ok := e.left.(*variableExpr); ok {
+				c.innerExpr(e.right)
+				if v.local >= 1 {
+					c.emit(exec.OpStoreLocal, v.local)
+				} else {
+					c.emit(exec.OpStoreGlobal, c.name(v.name))
+				}
+				return
+			}
+		} else if e.fns == nil && fusionOf(e) == nil {
+			c.innerExpr(e.right)
+			c.expr(e.left)
+			c.emit(applyOp(e.op, exec.OpBinary, exec.OpEvalBinary), c.name(e.op))
+			return
+		}
+	case value.Value:
+		c.emit(exec.OpConst, c.constant(e))
+		return
+	}
+	c.emit(exec.OpExpr, c.tree(e))
+}
+
+// innerExpr compiles an expression whose Inner value is wanted.
+func (c *compiler) innerExpr(e value.Expr) {
+	c.expr(e)
+	if _, ok := e.(value.Value); !ok {
+		// Constants are their own Inner values.
+		c.emit(exec.OpInner, 0)
+	}
+}
+
+// applyOp returns the opcode that applies op: plain for an op named
+// simply, such as + or a user-defined op, and eval for an operator
+// such as a reduction or product.
+func applyOp(op string, plain, eval exec.Opcode) exec.Opcode {
+	if baseOp(op) != op || strings.Contains(op, ".") {
+		return eval
+	}
+	return plain
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+import (
+	"strings"
+
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/value"
+)
+
+// Constant folding. A sub-expression of an op body that applies pure
+// builtin ops to constants, such as iota 10 or 2**64, is computed the
+// first time it is evaluated and reused after that, rather than computed
+// each time the op is called. Computing it lazily means defining an op
+// costs nothing however big the constant, as in 1e9 rho 1. Top-level
+// lines run once, so they are not folded.
+//
+// Only constants are moved out of the evaluation this way. Expressions
+// that use variables are evaluated where they appear, even if their
+// value cannot change during a loop.
+
+// constant is a folded constant sub-expression.
+type constant struct {
+	expr     value.Expr  // The expression as written.
+	value    value.Value // Its value, or nil if not yet computed.
+	ops      []namedOp   // The ops it applies, which must not be redefined.
+	settings settings    // The settings with which the value was computed.
+}
+
+// settings are the parts of the configuration that may affect the
+// value of a constant expression.
+type settings struct {
+	origin    int
+	floatPrec uint
+	float64   bool
+	maxBits   uint
+}
+
+func settingsOf(conf *config.Config) settings {
+	return settings{
+		origin:    conf.Origin(),
+		floatPrec: conf.FloatPrec(),
+		float64:   conf.Float64(),
+		maxBits:   conf.MaxBits(),
+	}
+}
+
+func (k *constant) ProgString() string {
+	return k.expr.ProgString()
+}
+
+// Eval returns the value of the expression, computing it if this is
+// the first evaluation or the settings have changed since the last one.
+// If an op it applies has been redefined, the expression is evaluated
+// afresh each time.
+func (k *constant) Eval(context value.Context) value.Value {
+	for _, op := range k.ops {
+		if context.UserDefined(op.name, op.isBinary) {
+			return k.expr.Eval(context)
+		}
+	}
+	if s := settingsOf(context.Config()); k.value == nil || s != k.settings {
+		k.value = k.expr.Eval(context).Inner()
+		k.settings = s
+	}
+	// Indexed assignment updates vectors and matrices in place,
+	// so each evaluation must yield a new one.
+	switch v := k.value.(type) {
+	case value.Vector:
+		return v.Copy()
+	case *value.Matrix:
+		return v.Copy()
+	}
+	return k.value
+}
+
+// fold returns e with its constant sub-expressions folded. It reports
+// whether it folded anything.
+func (p *Parser) fold(e value.Expr) (value.Expr, bool) {
+	folded := false
+	sub := func(x value.Expr) value.Expr {
+		x, ok := p.fold(x)
+		folded = folded || ok
+		return x
+	}
+	switch e := e.(type) {
+	case *unary:
+		e.right = sub(e.right)
+		if e.fns == nil {
+			if k := p.constant(e, e.op, false, e.right); k != nil {
+				return k, true
+			}
+		}
+	case *binary:
+		e.right = sub(e.right)
+		if e.op == "=" {
+			// The left side is assigned to, but its indexes are expressions.
+			if x, ok := e.left.(*index); ok {
+				sub(x)
+			}
+			break
+		}
+		e.left = sub(e.left)
+		if e.fns == nil {
+			if k := p.constant(e, e.op, true, e.left, e.right); k != nil {
+				return k, true
+			}
+		}
+	case conditional:
+		sub(e.binary)
+	case *index:
+		for i, x := range e.right {
+			if x != nil {
+				e.right[i] = sub(x)
+			}
+		}
+		if _, ok := e.left.(*variableExpr); !ok {
+			e.left = sub(e.left)
+		}
+	case sliceExpr:
+		for i, x := range e {
+			e[i] = sub(x)
+		}
+	case *derived:
+		e.right = sub(e.right)
+		if e.left != nil {
+			e.left = sub(e.left)
+		}
+	case *tryExpr:
+		e.expr = sub(e.expr)
+		e.fallback = sub(e.fallback)
+	}
+	return e, folded
+}
+
+// constant returns the folded form of e, the application of op to the
+// operands, or nil if it cannot be folded: the operands are not all
+// constants, or an op is not pure or has been redefined. Only the
+// outermost constant keeps its value, so the operands are unfolded.
+func (p *Parser) constant(e value.Expr, op string, isBinary bool, operands ...value.Expr) *constant {
+	for _, x := range operands {
+		if !isConstant(x) {
+			return nil
+		}
+	}
+	ops := opsUsed(op, isBinary)
+	for _, op := range ops {
+		if !value.PureOp(op.name, op.isBinary) || p.context.UserDefined(op.name, op.isBinary) {
+			return nil
+		}
+	}
+	switch e := e.(type) {
+	case *unary:
+		e.right = unfold(e.right)
+	case *binary:
+		e.left = unfold(e.left)
+		e.right = unfold(e.right)
+	}
+	return &constant{expr: e, ops: ops}
+}
+
+// unfold returns the constant expression e with any folded
+// constants replaced by the expressions they hold.
+func unfold(e value.Expr) value.Expr {
+	switch e := e.(type) {
+	case *constant:
+		return e.expr
+	case sliceExpr:
+		for i, x := range e {
+			e[i] = unfold(x)
+		}
+	}
+	return e
+}
+
+// isConstant reports whether e is a constant: a number, a vector of
+// numbers, or a folded expression.
+func isConstant(e value.Expr) bool {
+	switch e := e.(type) {
+	case value.Value, *constant:
+		return true
+	case sliceExpr:
+		for _, x := range e {
+			if !isConstant(x) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// opsUsed returns the ops applied by the operator op, which may be a
+// reduction, scan, product or each of a simpler op.
+func opsUsed(op string, isBinary bool) []namedOp {
+	if len(op) > 1 && strings.HasSuffix(op, "@") {
+		return []namedOp{{op[:len(op)-1], isBinary}}
+	}
+	if base := baseOp(op); base != op {
+		// A reduction or scan, or for a binary op an n-wise reduction.
+		return []namedOp{{base, true}}
+	}
+	if isBinary && strings.Contains(op, ".") {
+		var ops []namedOp
+		for _, name := range strings.SplitN(op, ".", 2) {
+			if name != "o" {
+				ops = append(ops, namedOp{name, true})
+			}
+		}
+		return ops
+	}
+	return []namedOp{{op, isBinary}}
+}
+
+// foldAll folds the constant sub-expressions of exprs, printing the
+// folded trees if debugging.
+func (p *Parser) foldAll(exprs []value.Expr) {
+	folded := false
+	for i, e := range exprs {
+		var ok bool
+		exprs[i], ok = p.fold(e)
+		folded = folded || ok
+	}
+	if folded && p.context.Config().Debug("parse") {
+		p.Println("folded:", tree(exprs))
+	}
+}
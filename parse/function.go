@@ -6,6 +6,7 @@ package parse
 
 import (
 	"fmt"
+	"strings"
 
 	"robpike.io/ivy/exec"
 	"robpike.io/ivy/scan"
@@ -18,21 +19,39 @@ import (
 //	"op" name arg '=' statements <eol>
 //	"op" arg name arg '=' statements <eol>
 //
+// A higher-order op has an op operand, in parentheses, before its name:
+//
+//	"op" '(' opname ')' name arg '=' statements <eol>
+//	"op" arg '(' opname ')' name arg '=' statements <eol>
+//
 // statements:
 //	expressionList
 //	'\n' (expressionList '\n')+ '\n' # For multiline definition, ending with blank line.
 //
+// The lines of a multiline definition may include loops, as described by body.
+//
 func (p *Parser) functionDefn() {
 	p.need(scan.Op)
 	fn := new(exec.Function)
 	// Two identifiers means: op arg.
 	// Three identifiers means: arg op arg.
+	// The op operand, if any, must come just before the op.
 	idents := make([]string, 2, 3)
+	opArgAt := -1
+	if p.opArg(fn) {
+		opArgAt = 0
+	}
 	idents[0] = p.need(scan.Identifier).Text
+	if opArgAt < 0 && p.opArg(fn) {
+		opArgAt = 1
+	}
 	idents[1] = p.need(scan.Identifier).Text
 	if p.peek().Type == scan.Identifier {
 		idents = append(idents, p.next().Text)
 	}
+	if opArgAt >= 0 && opArgAt != len(idents)-2 {
+		p.errorf("op operand (%s) must precede the operator name", fn.OpArg)
+	}
 	tok := p.next()
 	// Install the function in the symbol table so recursive ops work. (As if.)
 	var installMap map[string]*exec.Function
@@ -56,9 +75,17 @@ func (p *Parser) functionDefn() {
 	if fn.Name == fn.Left || fn.Name == fn.Right {
 		p.errorf("argument name %q is function name", fn.Name)
 	}
+	if fn.OpArg != "" {
+		if fn.OpArg == fn.Name || fn.OpArg == fn.Left || fn.OpArg == fn.Right {
+			p.errorf("op operand name %q is already used", fn.OpArg)
+		}
+		p.context.DeclareOpArg(fn.OpArg)
+	}
 	// Define it, but prepare to undefine if there's trouble.
 	p.context.Define(fn)
 	defer p.context.ForgetAll()
+	p.locals = make(map[string]bool)
+	defer func() { p.locals = nil }()
 	succeeded := false
 	prevDefn := installMap[fn.Name]
 	defer func() {
@@ -86,16 +113,8 @@ func (p *Parser) functionDefn() {
 			if !p.readTokensToNewline() {
 				p.errorf("invalid function definition")
 			}
-			for p.peek().Type != scan.EOF {
-				x, ok := p.expressionList()
-				if !ok {
-					p.errorf("invalid function definition")
-				}
-				fn.Body = append(fn.Body, x...)
-				if !p.readTokensToNewline() {
-					p.errorf("invalid function definition")
-				}
-			}
+			p.loops = 0
+			fn.Body = p.body(false)
 			p.next() // Consume final newline.
 		} else {
 			// Single line.
@@ -104,6 +123,7 @@ func (p *Parser) functionDefn() {
 			if !ok {
 				p.errorf("invalid function definition")
 			}
+			p.foldAll(fn.Body)
 		}
 		if len(fn.Body) == 0 {
 			p.errorf("missing function body")
@@ -114,10 +134,30 @@ func (p *Parser) functionDefn() {
 	}
 	p.context.Define(fn)
 	funcVars(fn)
+	if fn.Body != nil {
+		compile(fn)
+		fn.Calls = references(p.context, fn.Body)
+		fn.Effects = p.effects(fn.Body)
+	}
 	succeeded = true
 	if p.context.Config().Debug("parse") {
 		p.Printf("op %s %s %s = %s\n", fn.Left, fn.Name, fn.Right, tree(fn.Body))
 	}
+	if fn.Code != nil && p.context.Config().Debug("vm") {
+		p.Printf("%s", fn.Listing())
+	}
+}
+
+// opArg parses the op operand of a higher-order op definition,
+// if present, and reports whether it was.
+func (p *Parser) opArg(fn *exec.Function) bool {
+	if p.peek().Type != scan.LeftParen {
+		return false
+	}
+	p.next()
+	fn.OpArg = p.need(scan.Identifier).Text
+	p.need(scan.RightParen)
+	return true
 }
 
 // references returns a list, in appearance order, of the user-defined ops
@@ -125,23 +165,84 @@ func (p *Parser) functionDefn() {
 // entry in the list.
 func references(c *exec.Context, body []value.Expr) []exec.OpDef {
 	var refs []exec.OpDef
-	for _, expr := range body {
-		walk(expr, false, func(expr value.Expr, _ bool) {
-			switch e := expr.(type) {
-			case *unary:
-				if c.UnaryFn[e.op] != nil {
-					addReference(&refs, e.op, false)
-				}
-			case *binary:
-				if c.BinaryFn[e.op] != nil {
-					addReference(&refs, e.op, true)
-				}
+	var f func(expr value.Expr, _ bool)
+	f = func(expr value.Expr, _ bool) {
+		switch e := expr.(type) {
+		case *unary:
+			// An op applied with each, f@, refers to f.
+			op := strings.TrimSuffix(e.op, "@")
+			if c.UnaryFn[op] != nil {
+				addReference(&refs, op, false)
+			}
+		case *binary:
+			op := strings.TrimSuffix(e.op, "@")
+			if c.BinaryFn[op] != nil {
+				addReference(&refs, op, true)
 			}
-		})
+		case *derived:
+			if e.left == nil && c.UnaryFn[e.op] != nil {
+				addReference(&refs, e.op, false)
+			}
+			if e.left != nil && c.BinaryFn[e.op] != nil {
+				addReference(&refs, e.op, true)
+			}
+		case *lambda:
+			for _, expr := range e.fn.Body {
+				walk(expr, false, f)
+			}
+		}
+	}
+	for _, expr := range body {
+		walk(expr, false, f)
 	}
 	return refs
 }
 
+// effects reports whether the statements may do more than compute a
+// value, apart from what the user-defined ops they call do: assign to
+// a global, or to elements of a variable, which may be shared with the
+// caller; apply an impure builtin op such as ?; or apply an op value,
+// which could be anything.
+func (p *Parser) effects(body []value.Expr) bool {
+	effects := false
+	impure := func(op string, isBinary bool) bool {
+		for _, op := range opsUsed(op, isBinary) {
+			if !p.context.UserDefined(op.name, op.isBinary) && !value.PureOp(op.name, op.isBinary) {
+				return true
+			}
+		}
+		return false
+	}
+	var f func(expr value.Expr, assign bool)
+	f = func(expr value.Expr, assign bool) {
+		switch e := expr.(type) {
+		case *variableExpr:
+			effects = effects || assign && e.local == 0
+		case *unary:
+			effects = effects || e.fns != nil || impure(e.op, false)
+		case *binary:
+			switch e.op {
+			case "=":
+				_, indexed := e.left.(*index)
+				effects = effects || indexed
+			case ":":
+			default:
+				effects = effects || e.fns != nil || impure(e.op, true)
+			}
+		case *derived, *opOperand:
+			effects = true
+		case *lambda:
+			for _, expr := range e.fn.Body {
+				walk(expr, false, f)
+			}
+		}
+	}
+	for _, expr := range body {
+		walk(expr, false, f)
+	}
+	return effects
+}
+
 func addReference(refs *[]exec.OpDef, name string, isBinary bool) {
 	// If it's already there, ignore. This is n^2 but n is tiny.
 	for _, ref := range *refs {
@@ -178,6 +279,9 @@ func funcVars(fn *exec.Function) {
 	if fn.Right != "" {
 		addLocal(fn.Right)
 	}
+	if fn.OpArg != "" {
+		addLocal(fn.OpArg)
+	}
 	f := func(expr value.Expr, assign bool) {
 		switch e := expr.(type) {
 		case *variableExpr:
@@ -191,6 +295,8 @@ func funcVars(fn *exec.Function) {
 				x = known[e.name]
 			}
 			e.local = x
+		case *lambda:
+			lambdaVars(e, known)
 		}
 	}
 	for _, e := range fn.Body {
@@ -199,6 +305,20 @@ func funcVars(fn *exec.Function) {
 	return
 }
 
+// topLevelVars sets the locals of the anonymous ops in the top-level
+// statements. There is nothing to capture, so their free variables
+// are globals.
+func topLevelVars(exprs []value.Expr) {
+	f := func(expr value.Expr, _ bool) {
+		if l, ok := expr.(*lambda); ok {
+			lambdaVars(l, nil)
+		}
+	}
+	for _, e := range exprs {
+		walk(e, false, f)
+	}
+}
+
 // walk traverses expr in right-to-left order,
 // calling f on all children, with the boolean argument
 // specifying whether the expression is being assigned to,
@@ -207,11 +327,34 @@ func walk(expr value.Expr, assign bool, f func(value.Expr, bool)) {
 	switch e := expr.(type) {
 	case *unary:
 		walk(e.right, false, f)
+		walkOps(e.fns, f)
+	case *derived:
+		walk(e.right, false, f)
+		if e.left != nil {
+			walk(e.left, false, f)
+		}
+		walk(e.operand, false, f)
+	case *opOperand:
+		walkOps(e.fns, f)
 	case conditional:
 		walk(e.binary, false, f)
+	case *loop:
+		// The statements run in order after the loop's expression.
+		walk(e.expr, false, f)
+		if e.variable != nil {
+			walk(e.variable, true, f)
+		}
+		for _, stmt := range e.body {
+			walk(stmt, false, f)
+		}
+	case jump:
+	case *tryExpr:
+		walk(e.expr, false, f)
+		walk(e.fallback, false, f)
 	case *binary:
 		walk(e.right, false, f)
 		walk(e.left, e.op == "=", f)
+		walkOps(e.fns, f)
 	case *index:
 		for i := len(e.right) - 1; i >= 0; i-- {
 			x := e.right[i]
@@ -221,6 +364,9 @@ func walk(expr value.Expr, assign bool, f func(value.Expr, bool)) {
 		}
 		walk(e.left, false, f)
 	case *variableExpr:
+	case *constant:
+	case *lambda:
+		// The body is a separate scope.
 	case sliceExpr:
 		for i := len(e) - 1; i >= 0; i-- {
 			walk(e[i], false, f)
@@ -230,6 +376,7 @@ func walk(expr value.Expr, assign bool, f func(value.Expr, bool)) {
 	case value.BigInt:
 	case value.BigRat:
 	case value.BigFloat:
+	case value.Float64:
 	case value.Complex:
 	case value.Vector:
 	case *value.Matrix:
@@ -238,3 +385,10 @@ func walk(expr value.Expr, assign bool, f func(value.Expr, bool)) {
 	}
 	f(expr, assign)
 }
+
+// walkOps walks the expressions for the op values used by an operator.
+func walkOps(fns []opValue, f func(value.Expr, bool)) {
+	for i := len(fns) - 1; i >= 0; i-- {
+		walk(fns[i].expr, false, f)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+import (
+	"sync"
+
+	"robpike.io/ivy/value"
+)
+
+// fuser holds the fusion plan for a unary or binary node, worked out
+// when the node is first evaluated.
+type fuser struct {
+	once sync.Once
+	plan *fusion
+}
+
+// eval evaluates e, the node holding f, as a fused chain of ops if it
+// is one. It reports false if it is not, in which case e must be
+// evaluated as usual.
+func (f *fuser) eval(context value.Context, e value.Expr) (value.Value, bool) {
+	f.once.Do(func() {
+		f.plan = fusionOf(e)
+	})
+	if f.plan == nil {
+		return nil, false
+	}
+	return f.plan.eval(context)
+}
+
+// fusion is the plan for evaluating a chain of elementwise ops in one
+// pass over the elements of their operands; see value.EvalFused.
+type fusion struct {
+	tree   *value.Fused
+	ops    []namedOp    // The ops in the chain.
+	leaves []value.Expr // The operands, in the order they are evaluated.
+}
+
+// namedOp is an op, unary or binary, referred to by name.
+type namedOp struct {
+	name     string
+	isBinary bool
+}
+
+// fusionOf returns the fusion plan for e, or nil if e is not a chain of
+// at least two fusible ops whose operands are constants or variables.
+// Evaluating such operands has no effects, so they can all be evaluated
+// before any of the ops.
+func fusionOf(e value.Expr) *fusion {
+	f := new(fusion)
+	f.tree = f.build(e)
+	if f.tree == nil || len(f.ops) < 2 {
+		return nil
+	}
+	return f
+}
+
+// build returns the tree for e, or nil if an operand is not a constant
+// or variable.
+func (f *fusion) build(e value.Expr) *value.Fused {
+	switch e := e.(type) {
+	case *binary:
+		if e.fns == nil && value.FusibleOp(e.op, true) {
+			// Right first, as that is the order of evaluation.
+			right := f.build(e.right)
+			left := f.build(e.left)
+			if left == nil || right == nil {
+				return nil
+			}
+			f.ops = append(f.ops, namedOp{e.op, true})
+			return &value.Fused{Op: e.op, Left: left, Right: right}
+		}
+	case *unary:
+		if e.fns == nil && value.FusibleOp(e.op, false) {
+			right := f.build(e.right)
+			if right == nil {
+				return nil
+			}
+			f.ops = append(f.ops, namedOp{e.op, false})
+			return &value.Fused{Op: e.op, Right: right}
+		}
+	}
+	if !isConstOrVar(e) {
+		return nil
+	}
+	f.leaves = append(f.leaves, e)
+	return &value.Fused{Leaf: len(f.leaves) - 1}
+}
+
+// isConstOrVar reports whether e is a constant, a vector of constants,
+// a folded constant expression, or a variable.
+func isConstOrVar(e value.Expr) bool {
+	switch e := e.(type) {
+	case *variableExpr, *constant:
+		return true
+	case sliceExpr:
+		for _, x := range e {
+			if _, ok := x.(value.Value); !ok {
+				return false
+			}
+		}
+		return true
+	case value.Value:
+		return true
+	}
+	return false
+}
+
+// eval evaluates the chain. It reports false if it cannot, because an
+// op has been redefined or a variable is undefined, leaving the error,
+// if any, to the usual evaluation.
+func (f *fusion) eval(context value.Context) (value.Value, bool) {
+	for _, op := range f.ops {
+		if context.UserDefined(op.name, op.isBinary) {
+			return nil, false
+		}
+	}
+	operands := make([]value.Value, len(f.leaves))
+	for i, e := range f.leaves {
+		if v, ok := e.(*variableExpr); ok {
+			var x value.Value
+			if v.local >= 1 {
+				x = context.Local(v.local)
+			} else {
+				x = context.Global(v.name)
+			}
+			if x == nil {
+				return nil, false
+			}
+			operands[i] = x.Inner()
+			continue
+		}
+		operands[i] = e.Eval(context).Inner()
+	}
+	return value.EvalFused(context, f.tree, operands), true
+}
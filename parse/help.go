@@ -65,6 +65,10 @@ var helpLines = []string{
 	"",
 	"\tName              APL   Ivy     Meaning",
 	"\tRoll              ?B    ?       One integer selected randomly from the first B integers",
+	"\tUniform                 uniform Array of shape B of floats chosen randomly from [0, 1)",
+	"\tNormal                  normal  Array of shape B of normally distributed floats, mean 0 and standard deviation 1",
+	"\tExponential             expo    Array of shape B of exponentially distributed floats, mean 1",
+	"\tShuffle                 shuffle The items of B in random order",
 	"\tCeiling           ⌈B    ceil    Least integer greater than or equal to B",
 	"\tFloor             ⌊B    floor   Greatest integer less than or equal to B",
 	"\tShape             ⍴B    rho     Number of components in each dimension of B",
@@ -77,16 +81,26 @@ var helpLines = []string{
 	"\tSignum            ×B    sgn     ¯1 if B<0; 0 if B=0; 1 if B>0",
 	"\tReciprocal        ÷B    /       1 divided by B",
 	"\tRavel             ,B    ,       Reshapes B into a vector",
-	"\tMatrix inverse    ⌹B            Inverse of matrix B",
+	"\tMatrix inverse    ⌹B    inv     Inverse of matrix B; pseudo-inverse if B has more rows than columns",
 	"\tPi times          ○B            Multiply by π",
 	"\tLogarithm         ⍟B    log     Natural logarithm of B",
 	"\tReversal          ⌽B    rot     Reverse elements of B along last axis",
 	"\tReversal          ⊖B    flip    Reverse elements of B along first axis",
 	"\tGrade up          ⍋B    up      Indices of B which will arrange B in ascending order",
 	"\tGrade down        ⍒B    down    Indices of B which will arrange B in descending order",
+	"\tUnique            ∪B    unique  Elements of B without repeats, in order of first occurrence",
+	"\tCount                   count   Matrix of the distinct elements of B and the number of times each occurs",
+	"\tMean                    mean    Arithmetic mean of B; of each column if B is a matrix",
+	"\tMedian                  median  Middle value of B, or the mean of the two middle values",
+	"\tMode                    mode    Most frequent element of B, the first to occur if there is a tie",
+	"\tVariance                var     Sample variance of B, dividing by one less than the count",
+	"\tStandard deviation      sdev    Square root of the sample variance of B",
 	"\tExecute           ⍎B    ivy     Execute an APL (ivy) expression",
 	"\tMonadic format    ⍕B    text    A character representation of B",
 	"\tMonadic transpose ⍉B    transp  Reverse the axes of B",
+	"\tEnclose           ⊂B    box     A scalar holding B, so it can be an element of a vector",
+	"\tDisclose          ⊃B    unbox   The contents of box B; boxes in B are mixed into one array",
+	"\tDepth             ≡B    depth   Levels of nesting of boxes in B; 0 for a simple scalar",
 	"\tFactorial         !B    !       Product of integers 1 to B",
 	"\tBitwise not             ^       Bitwise complement of B (integer only)",
 	"\tSquare root       B⋆.5  sqrt    Square root of B.",
@@ -106,6 +120,7 @@ var helpLines = []string{
 	"\tReal part               real    Real component of the value",
 	"\tImaginary part          imag    Imaginary component of the value",
 	"\tPhase                   phase   Phase of the value in the complex plane (-π to π)",
+	"\tRaise error             raise   Fail with the text of B as the error message",
 	"",
 	"Binary operators",
 	"",
@@ -123,7 +138,29 @@ var helpLines = []string{
 	"\t                            cos     cos(B); ivy uses traditional name.",
 	"\t                            tan     tan(B); ivy uses traditional name.",
 	"\tDeal                  A?B   ?       A distinct integers selected randomly from the first B integers",
+	"\tUniform                     uniform Array of shape B of floats chosen randomly from [0, A), or from",
+	"\t                                    [A[1], A[2]) if A has two elements",
+	"\tNormal                      normal  Array of shape B of normally distributed floats with mean A[1]",
+	"\t                                    and standard deviation A[2], or 1 if A has one element",
+	"\tExponential                 expo    Array of shape B of exponentially distributed floats with rate A",
+	"\tPoisson                     poisson Array of shape B of integers with a Poisson distribution of mean A",
+	"\tBinomial                    binomial  Array of shape B of the numbers of successes in A[1] trials",
+	"\t                                    each with probability A[2]",
+	"\tWeighted sample             sample  Array of shape B of indices of A chosen randomly, with replacement,",
+	"\t                                    with probability proportional to the weights A",
 	"\tMembership            A∈B   in      1 for elements of A present in B; 0 where not.",
+	"\tUnion                 A∪B   union   A followed by the elements of B not in A",
+	"\tIntersection          A∩B   intersect  Elements of A that are also in B",
+	"\tWithout               A~B   without Elements of A that are not in B",
+	"\tInterval index        A⍸B   bin     Index of the interval between the ascending edges A holding each",
+	"\t                                    element of B: the number of edges ≤ B, less 1 if origin is 0",
+	"\tQuantile                    quantile  Value below which fraction A of the data B lies,",
+	"\t                                    interpolating between elements; of each column of a matrix",
+	"\tPercentile                  percentile  Value below which A percent of the data B lies",
+	"\tCovariance                  cov     Sample covariance of A and B",
+	"\tCorrelation                 corr    Correlation coefficient of A and B",
+	"\tWindows                     window  Matrix whose columns are the windows of A elements of B;",
+	"\t                                    median 3 window B gives the rolling median of B",
 	"\tMaximum               A⌈B   max     The greater value of A or B",
 	"\tMinimum               A⌊B   min     The smaller value of A or B",
 	"\tReshape               A⍴B   rho     Array of shape A with data B",
@@ -141,7 +178,8 @@ var helpLines = []string{
 	"\t                                    In ivy: abs(A) gives count, A <= 0 inserts zero",
 	"\tIndex of              A⍳B   iota    The location (index) of B in A; 1+⌈/⍳⍴A if not found",
 	"\t                                    In ivy: origin-1 if not found (i.e. 0 if one-indexed)",
-	"\tMatrix divide         A⌹B           Solution to system of linear equations Ax = B",
+	"\tMatrix divide         A⌹B   mdiv    Solution to system of linear equations Ax = B",
+	"\t                                    In ivy: least-squares solution if A has more rows than columns",
 	"\tRotation              A⌽B   rot     The elements of B are rotated A positions left",
 	"\tRotation              A⊖B   flip    The elements of B are rotated A positions along the first axis",
 	"\tLogarithm             A⍟B   log     Logarithm of B to base A",
@@ -174,12 +212,19 @@ var helpLines = []string{
 	"",
 	"\tName                APL  Ivy  APL Example  Ivy Example  Meaning (of example)",
 	"\tReduce (last axis)  /    /    +/B          +/B          Sum across B",
-	"\tReduce (first axis) ⌿         +⌿B                       Sum down B",
+	"\tN-wise reduce       /    /    N+/B         N +/ B       Sums of windows of N elements of B",
+	"\t                                                    (each window is taken backwards if N is negative)",
+	"\tReduce (first axis) ⌿    /%   +⌿B          +/%B         Sum down B",
 	"\tScan (last axis)    \\    \\    +\\B          +\\B          Running sum across B",
-	"\tScan (first axis)   ⍀         +⍀B                       Running sum down B",
+	"\tScan (first axis)   ⍀    \\%   +⍀B          +\\%B         Running sum down B",
 	"\tInner product       .    .    A+.×B        A +.* B      Matrix product of A and B",
 	"\tOuter product       ∘.   o.   A∘.×B        A o.* B      Outer product of A and B",
 	"\t                                                    (lower case o; may need preceding space)",
+	"\tEach                ¨    @    f¨B          f@ B         Apply f to each item of B",
+	"\t                                                    (A f@ B applies f to items of A and B)",
+	"\tPower               ⍣    power f⍣N B       N f power B  Apply f to B N times",
+	"\t                                                    (f power B applies f until the result stops changing;",
+	"\t                                                    (g) f power B until new g previous is true)",
 	"",
 	"Type-converting operations",
 	"",
@@ -189,6 +234,10 @@ var helpLines = []string{
 	"\tFloat                   float B The floating-point representation of B;",
 	"\t                                for complex numbers, the result is",
 	"\t                                (float A)j(float B)",
+	"\tJSON                    json B  The JSON representation of B, as a string",
+	"\t                                (see \"JSON\" below)",
+	"\tFrom JSON               fromjson B",
+	"\t                                The value represented by the JSON string B",
 	"",
 	"Pre-defined constants",
 	"",
@@ -219,6 +268,41 @@ var helpLines = []string{
 	"singleton values (ints, floats, and so on). The unary operators char and code",
 	"enable transcoding between integer and char values.",
 	"",
+	"Boxes",
+	"",
+	"The elements of vectors and matrices are scalars. To hold values of other",
+	"shapes, such as a list of names of differing lengths, enclose them in a box,",
+	"which is a scalar. Boxes print with a frame around their contents.",
+	"",
+	"\tnames = (box 'ann') (box 'bob') (box 'carol')",
+	"\trho names",
+	"\tresult: 3",
+	"\tunbox names[3]",
+	"\tresult: carol",
+	"",
+	"Elementwise operators apply to the contents of boxes and box the result, so",
+	"(box 1 2) + 10 is (box 11 12). Unbox of a vector or matrix of boxes mixes the",
+	"contents into an array with an extra axis, padding shorter items with zeros",
+	"(blanks for chars).",
+	"",
+	"JSON",
+	"",
+	"The unary operators json and fromjson convert between values and JSON text.",
+	"Integers become JSON numbers, floats become JSON numbers with a decimal point",
+	"or exponent, and a char or vector of chars becomes a JSON string. Other vectors",
+	"become JSON arrays. Rationals, complex numbers, and matrices become objects:",
+	"",
+	"\tjson 1/3",
+	"\tresult: {\"rat\": \"1/3\"}",
+	"\tjson 1j2",
+	"\tresult: {\"re\": 1, \"im\": 2}",
+	"\tjson 2 3 rho iota 6",
+	"\tresult: {\"shape\": [2, 3], \"data\": [[1, 2, 3], [4, 5, 6]]}",
+	"",
+	"When converting from JSON, true and false become 1 and 0, null is an error,",
+	"and nested arrays of equal length become a matrix. The -json flag prints each",
+	"result as JSON on a line of its own, for use by other programs.",
+	"",
 	"User-defined operators",
 	"",
 	"Users can define unary and binary operators, which then behave just like",
@@ -276,6 +360,38 @@ var helpLines = []string{
 	"\t1562 gcd !11",
 	"\tresult: 22",
 	"",
+	"The lines of a multiline operator may include loops, which repeat the",
+	"lines between the loop's first line and a line holding \":end\". A \":while\"",
+	"loop runs while its scalar condition is non-zero; a \":for\" loop assigns each",
+	"item of a vector, or each row of a matrix, to its variable in turn. Within a",
+	"loop, \":break\" leaves the loop and \":continue\" starts its next iteration.",
+	"Either may be the right operand of \":\", and a \":\" return inside a loop",
+	"returns from the operator.",
+	"",
+	"Example: factorial with a loop (unary):",
+	"\top fac n =",
+	"\t\tr = 1",
+	"\t\t:while n > 1",
+	"\t\t\tr = r*n",
+	"\t\t\tn = n-1",
+	"\t\t:end",
+	"\t\tr",
+	"",
+	"\tfac 10",
+	"\tresult: 3628800",
+	"",
+	"Example: sum of the elements up to the first negative one (unary):",
+	"\top total v =",
+	"\t\tt = 0",
+	"\t\t:for x in v",
+	"\t\t\tx < 0: :break",
+	"\t\t\tt = t+x",
+	"\t\t:end",
+	"\t\tt",
+	"",
+	"\ttotal 1 2 3 -4 5",
+	"\tresult: 6",
+	"",
 	"On mobile platforms only, due to I/O restrictions, user-defined operators",
 	"must be presented on a single line. Use semicolons to separate expressions:",
 	"",
@@ -305,6 +421,111 @@ var helpLines = []string{
 	"\ttotal last",
 	"\tresult: 12 3",
 	"",
+	"Anonymous operators",
+	"",
+	"An operator may also be written in place, without a name, as a body in braces.",
+	"Within the body, x is the argument of a unary operator and y is absent; for a",
+	"binary operator, x is the left argument and y the right. As with 'op', the",
+	"body may hold several expressions separated by semicolons, including \":\"",
+	"conditional returns. An anonymous operator can be used wherever an operator",
+	"can, including in reductions, scans, products and with each:",
+	"",
+	"\t{x*2} 1 2 3",
+	"\tresult: 2 4 6",
+	"\t{x max y}/ 3 1 4 1 5",
+	"\tresult: 5",
+	"\t1 2 3 +.{x*y} 4 5 6",
+	"\tresult: 32",
+	"",
+	"An anonymous operator that is not applied is a value, which can be assigned to",
+	"a variable. The variable may then be used as an operator, and may refer to",
+	"itself:",
+	"",
+	"\tfact = {x <= 1: 1; x * fact x-1}",
+	"\tfact 5",
+	"\tresult: 120",
+	"",
+	"Within a user-defined operator, an anonymous operator captures the values of",
+	"the local variables it reads, as they are when it is evaluated, so it can be",
+	"returned as the result:",
+	"",
+	"\top scale n = {x*n}",
+	"\ttriple = scale 3",
+	"\ttriple 1 2 3",
+	"\tresult: 3 6 9",
+	"",
+	"Higher-order operators",
+	"",
+	"A user-defined operator may take an operator as an operand, written in",
+	"parentheses before its name in the definition. Within the body, the operand",
+	"is used like any other operator. When the higher-order operator is applied,",
+	"the operand, which may be built-in, user-defined or anonymous, is written",
+	"before its name:",
+	"",
+	"\top (f) twice x = f f x",
+	"\t{x*2} twice 3",
+	"\tresult: 12",
+	"\top a (f) both b = (a f b) (b f a)",
+	"\t3 - both 10",
+	"\tresult: -7 7",
+	"",
+	"An operator that uses its operand in a reduction:",
+	"",
+	"\top (f) fold v = f/ v",
+	"\tmax fold 3 1 4 1 5",
+	"\tresult: 5",
+	"",
+	"Repeated application until the result stops changing:",
+	"",
+	"\top (f) fix x =",
+	"\t\ty = f x",
+	"\t\ty == x: x",
+	"\t\tf fix y",
+	"",
+	"\t{floor x/2} fix 100",
+	"\tresult: 0",
+	"",
+	"The built-in higher-order op power does this directly. With a left",
+	"argument, it applies its operand that many times; without one, it applies",
+	"it until the result stops changing, comparing floating-point results to",
+	"within the last few bits of precision. If the left argument is itself an",
+	"op, it is a condition: the operand is applied until the condition, given",
+	"the new result as its left argument and the previous one as its right,",
+	"is true. Power gives up with an error after about a million iterations.",
+	"",
+	"\t{floor x/2} power 100",
+	"\tresult: 0",
+	"\t3 sqrt power 256",
+	"\tresult: 2",
+	"\t{(x + 2/x)/2} power float 1.5",
+	"\tresult: 1.41421356237",
+	"\t({x > 100}) {x*2} power 1",
+	"\tresult: 128",
+	"",
+	"Trapping errors",
+	"",
+	"An expression that may fail can be trapped with try, written",
+	"",
+	"\ttry expression : fallback",
+	"",
+	"If the expression succeeds, its value is the result. If it fails, such as",
+	"by dividing by zero or with a length mismatch, the fallback is evaluated",
+	"instead. The \":\" separates the two and binds more loosely than any operator,",
+	"so parenthesize a try within a larger expression. If the fallback is an",
+	"anonymous operator, it is applied to the error message as a char vector.",
+	"The raise operator fails with an error of one's own.",
+	"",
+	"\ttry 1 / 0 : 0",
+	"\tresult: 0",
+	"\ttry 1 2 + 3 4 5 : {'caught: ', x}",
+	"\tresult: caught: length mismatch: 2 3",
+	"\top check n =",
+	"\t\tn < 0: raise 'negative: ', text n",
+	"\t\tsqrt n",
+	"",
+	"\ttry check -4 : {x}",
+	"\tresult: negative: -4",
+	"",
 	"Special commands",
 	"",
 	"Ivy accepts a number of special commands, introduced by a right paren",
@@ -327,9 +548,24 @@ var helpLines = []string{
 	"\t) debug name 0|1",
 	"\t\tToggle or set the named debugging flag. With no argument, lists",
 	"\t\tthe settings.",
+	"\t\tUser-defined ops are compiled to bytecode when defined. The vm",
+	"\t\tflag prints the bytecode of each op as it is defined and checks",
+	"\t\tthe result of each call against that of walking the op's parse",
+	"\t\ttree, which runs the op twice. Ops that may do more than compute",
+	"\t\ta value, such as by assigning to a global, are run only once and",
+	"\t\tnot checked.",
 	"\t) demo",
 	"\t\tRun a line-by-line interactive demo. On mobile platforms,",
 	"\t\tuse the Demo menu option instead.",
+	"\t) float64 0",
+	"\t\tIf 1, floating-point values are native 64-bit floats with IEEE",
+	"\t\tsemantics, so for instance division of a float by zero, log 0",
+	"\t\tand atanh 1 give infinities. Arithmetic on them, and on Arrow",
+	"\t\tfloating-point columns, runs at hardware speed. Exact values",
+	"\t\tremain exact, so 1/0 is still an error, and results that are not",
+	"\t\treal, such as sqrt -1 and log -1, are complex, as they are",
+	"\t\totherwise, rather than NaN. If 0, the default, floating-point",
+	"\t\tvalues have the precision set by ) prec.",
 	"\t) format \"\"",
 	"\t\tSet the format for printing values. If empty, the output is printed",
 	"\t\tusing the output base. If non-empty, the format determines the",
@@ -368,7 +604,9 @@ var helpLines = []string{
 	"\t\t\"save.ivy\".",
 	"\t\t(Unimplemented on mobile.)",
 	"\t) seed 0",
-	"\t\tSet the seed for the ? operator.",
+	"\t\tSet the seed for the ? operator and the random distributions.",
+	"\t\tA given seed produces the same values however much of the",
+	"\t\tcomputation is done in parallel.",
 }
 
 type helpIndexPair struct {
@@ -376,99 +614,137 @@ type helpIndexPair struct {
 }
 
 var helpUnary = map[string]helpIndexPair{
-	"?":      {61, 61},
-	"ceil":   {62, 62},
-	"floor":  {63, 63},
-	"rho":    {64, 64},
-	"not":    {65, 65},
-	"abs":    {66, 66},
-	"iota":   {67, 67},
-	"**":     {68, 68},
-	"-":      {69, 69},
-	"+":      {70, 70},
-	"sgn":    {71, 71},
-	"/":      {72, 72},
-	",":      {73, 73},
-	"log":    {76, 76},
-	"rot":    {77, 77},
-	"flip":   {78, 78},
-	"up":     {79, 79},
-	"down":   {80, 80},
-	"ivy":    {81, 81},
-	"text":   {82, 82},
-	"transp": {83, 83},
-	"!":      {84, 84},
-	"^":      {85, 85},
-	"sqrt":   {86, 86},
-	"sin":    {87, 87},
-	"cos":    {88, 88},
-	"tan":    {89, 89},
-	"asin":   {90, 90},
-	"acos":   {91, 91},
-	"atan":   {92, 92},
-	"sinh":   {93, 93},
-	"cosh":   {94, 94},
-	"tanh":   {95, 95},
-	"asinh":  {96, 96},
-	"acosh":  {97, 97},
-	"atanh":  {98, 98},
-	"j":      {99, 99},
-	"real":   {100, 100},
-	"imag":   {101, 101},
-	"phase":  {102, 102},
-	"code":   {181, 181},
-	"char":   {182, 182},
-	"float":  {183, 185},
+	"?":        {61, 61},
+	"uniform":  {62, 62},
+	"normal":   {63, 63},
+	"expo":     {64, 64},
+	"shuffle":  {65, 65},
+	"ceil":     {66, 66},
+	"floor":    {67, 67},
+	"rho":      {68, 68},
+	"not":      {69, 69},
+	"abs":      {70, 70},
+	"iota":     {71, 71},
+	"**":       {72, 72},
+	"-":        {73, 73},
+	"+":        {74, 74},
+	"sgn":      {75, 75},
+	"/":        {76, 76},
+	",":        {77, 77},
+	"inv":      {78, 78},
+	"log":      {80, 80},
+	"rot":      {81, 81},
+	"flip":     {82, 82},
+	"up":       {83, 83},
+	"down":     {84, 84},
+	"unique":   {85, 85},
+	"count":    {86, 86},
+	"mean":     {87, 87},
+	"median":   {88, 88},
+	"mode":     {89, 89},
+	"var":      {90, 90},
+	"sdev":     {91, 91},
+	"ivy":      {92, 92},
+	"text":     {93, 93},
+	"transp":   {94, 94},
+	"box":      {95, 95},
+	"unbox":    {96, 96},
+	"depth":    {97, 97},
+	"!":        {98, 98},
+	"^":        {99, 99},
+	"sqrt":     {100, 100},
+	"sin":      {101, 101},
+	"cos":      {102, 102},
+	"tan":      {103, 103},
+	"asin":     {104, 104},
+	"acos":     {105, 105},
+	"atan":     {106, 106},
+	"sinh":     {107, 107},
+	"cosh":     {108, 108},
+	"tanh":     {109, 109},
+	"asinh":    {110, 110},
+	"acosh":    {111, 111},
+	"atanh":    {112, 112},
+	"j":        {113, 113},
+	"real":     {114, 114},
+	"imag":     {115, 115},
+	"phase":    {116, 116},
+	"raise":    {117, 117},
+	"code":     {226, 226},
+	"char":     {227, 227},
+	"float":    {228, 230},
+	"json":     {231, 232},
+	"fromjson": {233, 234},
 }
 
 var helpBinary = map[string]helpIndexPair{
-	"+":      {107, 107},
-	"-":      {108, 108},
-	"*":      {109, 109},
-	"/":      {110, 112},
-	"**":     {113, 113},
-	"?":      {119, 119},
-	"in":     {120, 120},
-	"max":    {121, 121},
-	"min":    {122, 122},
-	"rho":    {123, 123},
-	"take":   {124, 124},
-	"drop":   {125, 125},
-	"decode": {126, 126},
-	"encode": {127, 127},
-	"mod":    {129, 130},
-	",":      {131, 131},
-	"fill":   {132, 133},
-	"sel":    {134, 135},
-	"iota":   {136, 137},
-	"rot":    {139, 139},
-	"flip":   {140, 140},
-	"log":    {141, 141},
-	"text":   {142, 146},
-	"transp": {147, 147},
-	"!":      {148, 148},
-	"<":      {149, 149},
-	"<=":     {150, 150},
-	"==":     {151, 151},
-	">=":     {152, 152},
-	">":      {153, 153},
-	"!=":     {154, 154},
-	"or":     {155, 155},
-	"and":    {156, 156},
-	"nor":    {157, 157},
-	"nand":   {158, 158},
-	"xor":    {159, 159},
-	"&":      {160, 160},
-	"|":      {161, 161},
-	"^":      {162, 162},
-	"<<":     {163, 163},
-	">>":     {164, 164},
-	"j":      {165, 165},
+	"+":          {122, 122},
+	"-":          {123, 123},
+	"*":          {124, 124},
+	"/":          {125, 127},
+	"**":         {128, 128},
+	"?":          {134, 134},
+	"uniform":    {135, 136},
+	"normal":     {137, 138},
+	"expo":       {139, 139},
+	"poisson":    {140, 140},
+	"binomial":   {141, 142},
+	"sample":     {143, 144},
+	"in":         {145, 145},
+	"union":      {146, 146},
+	"intersect":  {147, 147},
+	"without":    {148, 148},
+	"bin":        {149, 150},
+	"quantile":   {151, 152},
+	"percentile": {153, 153},
+	"cov":        {154, 154},
+	"corr":       {155, 155},
+	"window":     {156, 157},
+	"max":        {158, 158},
+	"min":        {159, 159},
+	"rho":        {160, 160},
+	"take":       {161, 161},
+	"drop":       {162, 162},
+	"decode":     {163, 163},
+	"encode":     {164, 164},
+	"mod":        {166, 167},
+	",":          {168, 168},
+	"fill":       {169, 170},
+	"sel":        {171, 172},
+	"iota":       {173, 174},
+	"mdiv":       {175, 176},
+	"rot":        {177, 177},
+	"flip":       {178, 178},
+	"log":        {179, 179},
+	"text":       {180, 184},
+	"transp":     {185, 185},
+	"!":          {186, 186},
+	"<":          {187, 187},
+	"<=":         {188, 188},
+	"==":         {189, 189},
+	">=":         {190, 190},
+	">":          {191, 191},
+	"!=":         {192, 192},
+	"or":         {193, 193},
+	"and":        {194, 194},
+	"nor":        {195, 195},
+	"nand":       {196, 196},
+	"xor":        {197, 197},
+	"&":          {198, 198},
+	"|":          {199, 199},
+	"^":          {200, 200},
+	"<<":         {201, 201},
+	">>":         {202, 202},
+	"j":          {203, 203},
 }
 
 var helpAxis = map[string]helpIndexPair{
-	"/":  {170, 170},
-	"\\": {172, 172},
-	".":  {174, 174},
-	"o.": {175, 175},
+	"/":     {208, 209},
+	"/%":    {211, 211},
+	"\\":    {212, 212},
+	"\\%":   {213, 213},
+	".":     {214, 214},
+	"o.":    {215, 215},
+	"@":     {217, 217},
+	"power": {219, 219},
 }
@@ -193,6 +193,10 @@ func main() {
 	for i = 0; lines[i] != "Operators and axis indicator"; i++ {
 	}
 	s("var helpAxis = map[string]helpIndexPair{")
+	// An op may have consecutive lines, such as / for reduce
+	// and n-wise reduce. Its entry covers them all.
+	var axisOps []string
+	axisPairs := make(map[string][2]int)
 	for i++; i < len(lines); i++ {
 		line := lines[i]
 		if line == "Type-converting operations" {
@@ -216,7 +220,17 @@ func main() {
 		if len(op) == 0 {
 			continue
 		}
-		fmt.Fprintf(buf, `%q: {%d, %d},`+"\n", string(op), i, i)
+		pair, ok := axisPairs[string(op)]
+		if !ok {
+			axisOps = append(axisOps, string(op))
+			pair[0] = i
+		}
+		pair[1] = i
+		axisPairs[string(op)] = pair
+	}
+	for _, op := range axisOps {
+		pair := axisPairs[op]
+		fmt.Fprintf(buf, `%q: {%d, %d},`+"\n", op, pair[0], pair[1])
 	}
 	s("}")
 
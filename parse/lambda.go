@@ -0,0 +1,334 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+// Anonymous ops, such as {x*2} and {x max y}, and the op values they create.
+// In the body, x is the argument of a unary op, or the left argument of a
+// binary one, and y is the right argument. Variables of the enclosing op that
+// the body reads are captured when the anonymous op is evaluated; any other
+// variables are globals.
+//
+// Operator expressions refer to ops by name, so while an expression that uses
+// an anonymous op, or a variable holding one, as an operator is evaluated, the
+// op value is bound to a name in the context. Anonymous ops written in place
+// are named λ1, λ2 and so on, which is unambiguous within the expression.
+//
+// A higher-order op, defined with an op operand as in
+//	op (f) twice x = f f x
+// is applied to an operator written before its name, as in
+//	{x*2} twice 3
+// and the operand is held in a local variable of the op, here f, so
+// the expressions in its body that use it bind it like any op value.
+// Since each call of an op starts with no bindings, the ops it calls
+// do not see them.
+
+import (
+	"fmt"
+	"strings"
+
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/scan"
+	"robpike.io/ivy/value"
+)
+
+// lambda is an anonymous op, which evaluates to a value.Func.
+type lambda struct {
+	fn       *exec.Function
+	free     []string        // Variables other than x and y read before assignment, in order.
+	captures []*variableExpr // Variables captured from the enclosing op, as locals 3, 4, ....
+}
+
+func (l *lambda) ProgString() string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, e := range l.fn.Body {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(e.ProgString())
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (l *lambda) Eval(context value.Context) value.Value {
+	captured := make([]value.Value, len(l.captures))
+	for i, v := range l.captures {
+		captured[i] = v.Eval(context)
+	}
+	return value.NewFunc(&exec.Closure{Fn: l.fn, Captured: captured}, l.fn.Name)
+}
+
+// opValue is an operand of an operator expression that evaluates
+// to a value.Func: an anonymous op or a variable holding one.
+type opValue struct {
+	name string // The name by which the operator refers to it.
+	expr value.Expr
+}
+
+// bindOps binds the op values used by an operator expression and
+// returns a function that restores the previous bindings.
+func bindOps(context value.Context, fns []opValue) func() {
+	c := context.(*exec.Context)
+	restore := make([]func(), len(fns))
+	for i, f := range fns {
+		fn, ok := f.expr.Eval(context).(value.Func)
+		if !ok {
+			value.Errorf("%s is not an op", f.expr.ProgString())
+		}
+		restore[i] = c.BindOp(f.name, fn)
+	}
+	return func() {
+		for i := len(restore) - 1; i >= 0; i-- {
+			restore[i]()
+		}
+	}
+}
+
+// opString returns op as it appears in the source, with any
+// anonymous ops it uses written out in place of their names.
+func opString(op string, fns []opValue) string {
+	for _, f := range fns {
+		if l, ok := f.expr.(*lambda); ok {
+			op = strings.Replace(op, f.name, l.ProgString(), 1)
+		}
+	}
+	return op
+}
+
+// opValues returns the variables holding op values that are used by op,
+// which may be applied with a reduction, scan, product or each.
+func (p *Parser) opValues(op string) []opValue {
+	var fns []opValue
+	for _, name := range strings.Split(baseOp(op), ".") {
+		// The op operand of a higher-order op is a local variable of the op.
+		if p.context.OpVariable(name) || p.context.OpArg(name) {
+			fns = append(fns, opValue{name, p.variable(name)})
+		}
+	}
+	return fns
+}
+
+// baseOp returns op without any reduction, scan or each suffix.
+func baseOp(op string) string {
+	if len(op) > 1 {
+		op = strings.TrimSuffix(op, "@")
+	}
+	for _, suffix := range []string{"/%", `\%`, "/", `\`} {
+		if len(op) > len(suffix) && strings.HasSuffix(op, suffix) {
+			return op[:len(op)-len(suffix)]
+		}
+	}
+	return op
+}
+
+// lambda parses an anonymous op. The left brace has been consumed.
+// It returns the op and any suffix following the right brace.
+//
+// lambda
+//
+//	'{' statementList '}'
+func (p *Parser) lambda() (*lambda, string) {
+	mark := p.context.Declared()
+	p.context.Declare("x")
+	p.context.Declare("y")
+	body, _ := p.statementList()
+	p.context.Forget(mark)
+	tok := p.next()
+	if tok.Type != scan.RightBrace {
+		p.errorf("expected right brace, found %s", tok)
+	}
+	if len(body) == 0 {
+		p.errorf("missing op body")
+	}
+	p.foldAll(body)
+	l := &lambda{
+		fn:   &exec.Function{Body: body},
+		free: freeVars(body),
+	}
+	l.fn.Name = l.ProgString()
+	return l, tok.Text[1:]
+}
+
+// lambdaOp parses an operator made from an anonymous op, including any
+// reduction, scan, product or each that follows it. The left brace has
+// been consumed.
+func (p *Parser) lambdaOp() (string, []opValue) {
+	l, suffix := p.lambda()
+	fns := []opValue{{"λ1", l}}
+	op := "λ1" + suffix
+	if strings.HasPrefix(suffix, ".") {
+		if suffix != "." {
+			// A product with a named operator on the right.
+			return op, append(fns, p.opValues(suffix[1:])...)
+		}
+		op, fns = p.productLambda(op, fns)
+	}
+	return op, fns
+}
+
+// productLambda parses the anonymous op on the right of a product whose
+// left operator, ending in a period, is op. The op values in fns are
+// those used by op.
+func (p *Parser) productLambda(op string, fns []opValue) (string, []opValue) {
+	if tok := p.next(); tok.Type != scan.LeftBrace {
+		p.errorf("expected operator after %s, found %s", opString(op, fns), tok)
+	}
+	l, suffix := p.lambda()
+	if suffix != "" && suffix != "@" {
+		p.errorf("unexpected %s after %s", suffix, l.ProgString())
+	}
+	name := "λ1"
+	if len(fns) > 0 {
+		name = "λ2"
+	}
+	return op + name + suffix, append(fns, opValue{name, l})
+}
+
+// freeVars returns the variables, other than x and y, that the
+// statements read before assigning to them, including those read
+// by any anonymous ops they contain.
+func freeVars(body []value.Expr) []string {
+	var free []string
+	known := map[string]bool{"x": true, "y": true}
+	read := func(name string) {
+		if !known[name] {
+			known[name] = true
+			free = append(free, name)
+		}
+	}
+	f := func(expr value.Expr, assign bool) {
+		switch e := expr.(type) {
+		case *variableExpr:
+			if assign {
+				known[e.name] = true
+			} else {
+				read(e.name)
+			}
+		case *lambda:
+			for _, name := range e.free {
+				read(name)
+			}
+		}
+	}
+	for _, e := range body {
+		walk(e, false, f)
+	}
+	return free
+}
+
+// lambdaVars sets the locals of l, which appears in a scope whose
+// variables have the local indexes in outer, which is nil at top level.
+// The free variables of l that are locals of the outer scope are
+// captured; the others are globals.
+func lambdaVars(l *lambda, outer map[string]int) {
+	fn := l.fn
+	fn.Locals = []string{"x", "y"}
+	l.captures = nil
+	for _, name := range l.free {
+		if i := outer[name]; i > 0 {
+			fn.Locals = append(fn.Locals, name)
+			l.captures = append(l.captures, &variableExpr{name: name, local: i})
+		}
+	}
+	known := make(map[string]int)
+	for i, name := range fn.Locals {
+		known[name] = i + 1
+	}
+	f := func(expr value.Expr, assign bool) {
+		switch e := expr.(type) {
+		case *variableExpr:
+			x, ok := known[e.name]
+			if !ok {
+				if assign {
+					fn.Locals = append(fn.Locals, e.name)
+					known[e.name] = len(fn.Locals)
+				} else {
+					known[e.name] = 0
+				}
+				x = known[e.name]
+			}
+			e.local = x
+		case *lambda:
+			lambdaVars(e, known)
+		}
+	}
+	for _, e := range fn.Body {
+		walk(e, false, f)
+	}
+	compile(fn)
+}
+
+// opOperand is an operator used as the operand of a higher-order op.
+type opOperand struct {
+	op  string
+	fns []opValue // Op values used by op.
+}
+
+func (o *opOperand) ProgString() string {
+	return opString(o.op, o.fns)
+}
+
+func (o *opOperand) Eval(context value.Context) value.Value {
+	if len(o.fns) == 1 && o.fns[0].name == o.op {
+		// An anonymous op or a variable holding an op value.
+		return o.fns[0].expr.Eval(context)
+	}
+	defer bindOps(context, o.fns)()
+	return context.(*exec.Context).NewOpRef(o.op, strings.Split(baseOp(o.op), "."), o.ProgString())
+}
+
+// derived is the application of a higher-order op to its op operand
+// and its arguments. For a unary op, left is nil.
+type derived struct {
+	op      string
+	operand *opOperand
+	left    value.Expr
+	right   value.Expr
+}
+
+func (d *derived) ProgString() string {
+	s := fmt.Sprintf("%s %s %s", d.operand.ProgString(), d.op, d.right.ProgString())
+	if d.left == nil {
+		return s
+	}
+	if isCompound(d.left) {
+		return fmt.Sprintf("(%s) %s", d.left.ProgString(), s)
+	}
+	return fmt.Sprintf("%s %s", d.left.ProgString(), s)
+}
+
+func (d *derived) Eval(context value.Context) value.Value {
+	right := d.right.Eval(context).Inner()
+	var left value.Value
+	if d.left != nil {
+		left = d.left.Eval(context)
+	}
+	fn, ok := d.operand.Eval(context).(value.Func)
+	if !ok {
+		value.Errorf("%s is not an op", d.operand.ProgString())
+	}
+	return context.(*exec.Context).EvalHigher(left, d.op, fn, right)
+}
+
+// higherOrder reports whether the token is a higher-order op.
+func (p *Parser) higherOrder(tok scan.Token, isBinary bool) bool {
+	return tok.Type == scan.Identifier && p.context.HigherOrder(tok.Text, isBinary)
+}
+
+// derived parses the application of the higher-order op that is the next
+// token, with the operator op, which uses the op values fns, as its operand.
+// For a unary op, left is nil.
+func (p *Parser) derived(left value.Expr, op string, fns []opValue) value.Expr {
+	if name := baseOp(op); p.context.HigherOrder(name, false) || p.context.HigherOrder(name, true) {
+		p.errorf("%s: missing op operand", name)
+	}
+	return &derived{
+		op:      p.next().Text,
+		operand: &opOperand{op, fns},
+		left:    left,
+		right:   p.expr(),
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"robpike.io/ivy/scan"
+	"robpike.io/ivy/value"
+)
+
+// loop is a while or for loop in the body of a multiline op.
+type loop struct {
+	kind     string        // "while" or "for".
+	variable *variableExpr // The loop variable of a for loop.
+	expr     value.Expr    // The condition of a while loop, or the values of a for loop.
+	body     []value.Expr
+}
+
+var _ = value.Loop(&loop{})
+
+func (l *loop) ProgString() string {
+	var b strings.Builder
+	if l.variable != nil {
+		fmt.Fprintf(&b, ":%s %s in %s", l.kind, l.variable.ProgString(), l.expr.ProgString())
+	} else {
+		fmt.Fprintf(&b, ":%s %s", l.kind, l.expr.ProgString())
+	}
+	for _, stmt := range l.body {
+		b.WriteString("\n\t")
+		b.WriteString(strings.ReplaceAll(stmt.ProgString(), "\n", "\n\t"))
+	}
+	b.WriteString("\n:end")
+	return b.String()
+}
+
+func (l *loop) Eval(value.Context) value.Value {
+	value.Errorf(":%s outside op", l.kind)
+	return nil
+}
+
+func (l *loop) Cond() value.Expr {
+	if l.kind == "while" {
+		return l.expr
+	}
+	return nil
+}
+
+func (l *loop) Iterate(context value.Context) func() bool {
+	if l.kind == "while" {
+		return func() bool { return true }
+	}
+	items := value.Items(l.expr.Eval(context).Inner())
+	return func() bool {
+		if len(items) == 0 {
+			return false
+		}
+		if l.variable.local >= 1 {
+			context.AssignLocal(l.variable.local, items[0])
+		} else {
+			context.AssignGlobal(l.variable.name, items[0])
+		}
+		items = items[1:]
+		return true
+	}
+}
+
+func (l *loop) Body() []value.Expr {
+	return l.body
+}
+
+// jump is a break or continue statement in a loop.
+type jump struct {
+	isBreak bool
+}
+
+var _ = value.Jump(jump{})
+
+func (j jump) ProgString() string {
+	if j.isBreak {
+		return ":break"
+	}
+	return ":continue"
+}
+
+func (j jump) Eval(value.Context) value.Value {
+	value.Errorf("%s outside loop", j.ProgString())
+	return nil
+}
+
+func (j jump) IsBreak() bool {
+	return j.isBreak
+}
+
+// keyword returns the keyword, such as "while", if the next tokens
+// are a colon and an identifier, which begins a loop statement.
+func (p *Parser) keyword() string {
+	if p.peek().Type != scan.Colon || len(p.tokens) < 2 || p.tokens[1].Type != scan.Identifier {
+		return ""
+	}
+	return p.tokens[1].Text
+}
+
+// body parses the lines of a multiline op body up to the blank line that
+// ends the definition or, within a loop, up to the line that ends the loop.
+// The first line has been read.
+//
+// body
+//
+//	(line '\n')*
+//
+// line
+//
+//	expressionList
+//	':while' expr '\n' body ':end'
+//	':for' name 'in' expr '\n' body ':end'
+//	':break'
+//	':continue'
+func (p *Parser) body(inLoop bool) []value.Expr {
+	var body []value.Expr
+	for p.peek().Type != scan.EOF {
+		switch word := p.keyword(); word {
+		case "":
+			x, ok := p.expressionList()
+			if !ok {
+				p.errorf("invalid function definition")
+			}
+			p.foldAll(x)
+			body = append(body, x...)
+		case "end":
+			if !inLoop {
+				p.errorf(":end outside loop")
+			}
+			p.next()
+			p.next()
+			p.needEOL()
+			return body
+		case "while", "for":
+			body = append(body, p.loop())
+		case "break", "continue":
+			body = append(body, p.jump())
+			p.needEOL()
+		default:
+			p.errorf("unknown keyword :%s", word)
+		}
+		if !p.readTokensToNewline() {
+			p.errorf("invalid function definition")
+		}
+	}
+	if inLoop {
+		p.errorf("missing :end")
+	}
+	return body
+}
+
+// loop parses a while or for loop, through the line that ends it.
+func (p *Parser) loop() *loop {
+	p.next()
+	l := &loop{kind: p.next().Text}
+	if l.kind == "for" {
+		l.variable = p.variable(p.need(scan.Identifier).Text)
+		if tok := p.next(); tok.Type != scan.Identifier || tok.Text != "in" {
+			p.errorf("expected in after :for %s, found %s", l.variable.name, tok)
+		}
+	}
+	l.expr = p.expr()
+	if l.expr == nil {
+		p.errorf("missing expression after :%s", l.kind)
+	}
+	l.expr, _ = p.fold(l.expr)
+	p.needEOL()
+	if !p.readTokensToNewline() {
+		p.errorf("invalid function definition")
+	}
+	p.loops++
+	l.body = p.body(true)
+	p.loops--
+	return l
+}
+
+// jump parses a break or continue statement.
+func (p *Parser) jump() jump {
+	if p.loops == 0 {
+		p.errorf(":%s outside loop", p.tokens[1].Text)
+	}
+	p.next()
+	return jump{isBreak: p.next().Text == "break"}
+}
+
+// needEOL errors unless the line has been consumed.
+func (p *Parser) needEOL() {
+	if tok := p.next(); tok.Type != scan.EOF {
+		p.errorf("unexpected %s", tok)
+	}
+}
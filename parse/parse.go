@@ -26,6 +26,8 @@ func tree(e interface{}) string {
 		return fmt.Sprintf("<rat %s>", e)
 	case value.BigFloat:
 		return fmt.Sprintf("<float %s>", e)
+	case value.Float64:
+		return fmt.Sprintf("<float64 %s>", e)
 	case value.Complex:
 		return fmt.Sprintf("<complex %s>", e)
 	case sliceExpr:
@@ -41,11 +43,28 @@ func tree(e interface{}) string {
 	case *variableExpr:
 		return fmt.Sprintf("<var %s>", e.name)
 	case *unary:
-		return fmt.Sprintf("(%s %s)", e.op, tree(e.right))
+		return fmt.Sprintf("(%s %s)", opString(e.op, e.fns), tree(e.right))
 	case *binary:
-		return fmt.Sprintf("(%s %s %s)", tree(e.left), e.op, tree(e.right))
+		return fmt.Sprintf("(%s %s %s)", tree(e.left), opString(e.op, e.fns), tree(e.right))
+	case *derived:
+		if e.left == nil {
+			return fmt.Sprintf("((%s %s) %s)", e.operand.ProgString(), e.op, tree(e.right))
+		}
+		return fmt.Sprintf("(%s (%s %s) %s)", tree(e.left), e.operand.ProgString(), e.op, tree(e.right))
 	case conditional:
 		return tree(e.binary)
+	case *constant:
+		return fmt.Sprintf("<const %s>", e.ProgString())
+	case *loop:
+		s := fmt.Sprintf("(:%s ", e.kind)
+		if e.variable != nil {
+			s += tree(e.variable) + " in "
+		}
+		return s + tree(e.expr) + " " + tree(e.body) + ")"
+	case jump:
+		return e.ProgString()
+	case *tryExpr:
+		return "(try " + tree(e.expr) + " : " + tree(e.fallback) + ")"
 	case *index:
 		s := fmt.Sprintf("(%s[", tree(e.left))
 		for i, v := range e.right {
@@ -184,12 +203,14 @@ func (e *variableExpr) ProgString() string {
 // may require parentheses around it when printed to maintain correct evaluation order.
 func isCompound(x interface{}) bool {
 	switch x := x.(type) {
-	case value.Char, value.Int, value.BigInt, value.BigRat, value.BigFloat, value.Complex, value.Vector, value.Matrix:
+	case value.Char, value.Int, value.BigInt, value.BigRat, value.BigFloat, value.Float64, value.Complex, value.Vector, value.Matrix:
 		return false
 	case sliceExpr, *variableExpr:
 		return false
 	case *index:
 		return isCompound(x.left)
+	case *constant:
+		return isCompound(x.expr)
 	default:
 		return true
 	}
@@ -197,21 +218,32 @@ func isCompound(x interface{}) bool {
 
 type unary struct {
 	op    string
+	fns   []opValue // Op values used by op.
 	right value.Expr
+	fuser fuser
 }
 
 func (u *unary) ProgString() string {
-	return fmt.Sprintf("%s %s", u.op, u.right.ProgString())
+	return fmt.Sprintf("%s %s", opString(u.op, u.fns), u.right.ProgString())
 }
 
 func (u *unary) Eval(context value.Context) value.Value {
-	return context.EvalUnary(u.op, u.right.Eval(context).Inner())
+	if v, ok := u.fuser.eval(context, u); ok {
+		return v
+	}
+	right := u.right.Eval(context).Inner()
+	if u.fns != nil {
+		defer bindOps(context, u.fns)()
+	}
+	return context.EvalUnary(u.op, right)
 }
 
 type binary struct {
 	op    string
+	fns   []opValue // Op values used by op.
 	left  value.Expr
 	right value.Expr
+	fuser fuser
 }
 
 func (b *binary) ProgString() string {
@@ -221,15 +253,21 @@ func (b *binary) ProgString() string {
 	} else {
 		left = b.left.ProgString()
 	}
-	return fmt.Sprintf("%s %s %s", left, b.op, b.right.ProgString())
+	return fmt.Sprintf("%s %s %s", left, opString(b.op, b.fns), b.right.ProgString())
 }
 
 func (b *binary) Eval(context value.Context) value.Value {
 	if b.op == "=" {
 		return assignment(context, b)
 	}
+	if v, ok := b.fuser.eval(context, b); ok {
+		return v
+	}
 	rhs := b.right.Eval(context).Inner()
 	lhs := b.left.Eval(context)
+	if b.fns != nil {
+		defer bindOps(context, b.fns)()
+	}
 	return context.EvalBinary(lhs, b.op, rhs)
 }
 
@@ -288,6 +326,8 @@ type Parser struct {
 	fileName string
 	lineNum  int
 	context  *exec.Context
+	loops    int             // Depth of loops in the op body being parsed.
+	locals   map[string]bool // Variables assigned so far in the op body being parsed.
 }
 
 // NewParser returns a new parser that will read from the scanner.
@@ -366,6 +406,9 @@ func (p *Parser) Line() ([]value.Expr, bool) {
 	case scan.EOF:
 		return nil, true
 	case scan.RightParen:
+		if !p.context.Config().SpecialCommands() {
+			p.errorf("special commands are disabled")
+		}
 		p.special()
 		p.context.SetConstants()
 		return nil, true
@@ -374,9 +417,11 @@ func (p *Parser) Line() ([]value.Expr, bool) {
 		return nil, true
 	}
 	exprs, ok := p.expressionList()
+	p.context.ForgetAll()
 	if !ok {
 		return nil, false
 	}
+	topLevelVars(exprs)
 	return exprs, true
 }
 
@@ -428,11 +473,17 @@ func (p *Parser) statementList() ([]value.Expr, bool) {
 	expr := p.expr()
 	if expr != nil && p.peek().Type == scan.Colon {
 		tok := p.next()
+		var right value.Expr
+		if word := p.keyword(); word == "break" || word == "continue" {
+			right = p.jump()
+		} else {
+			right = p.expr()
+		}
 		expr = conditional{
 			&binary{
 				left:  expr,
 				op:    tok.Text,
-				right: p.expr(),
+				right: right,
 			},
 		}
 	}
@@ -459,14 +510,22 @@ func (p *Parser) expr() value.Expr {
 	expr := p.operand(tok, true)
 	tok = p.peek()
 	switch tok.Type {
-	case scan.EOF, scan.RightParen, scan.RightBrack, scan.Semicolon, scan.Colon:
+	case scan.EOF, scan.RightParen, scan.RightBrack, scan.RightBrace, scan.Semicolon, scan.Colon:
 		return expr
 	case scan.Identifier:
+		if p.context.DefinedOp(tok.Text) && len(p.tokens) > 1 && p.higherOrder(p.tokens[1], true) {
+			p.next()
+			return p.derived(expr, tok.Text, p.opValues(tok.Text))
+		}
+		if p.context.HigherOrder(tok.Text, true) || p.context.HigherOrder(tok.Text, false) {
+			p.errorf("%s: missing op operand", tok.Text)
+		}
 		if p.context.DefinedBinary(tok.Text) {
 			p.next()
 			return &binary{
 				left:  expr,
 				op:    tok.Text,
+				fns:   p.opValues(tok.Text),
 				right: p.expr(),
 			}
 		}
@@ -474,18 +533,51 @@ func (p *Parser) expr() value.Expr {
 		p.next()
 		switch lhs := expr.(type) {
 		case *variableExpr, *index:
+			v, isVar := lhs.(*variableExpr)
+			if isVar && p.peek().Type == scan.LeftBrace {
+				// Possibly an anonymous op, which may refer to itself.
+				p.context.DeclareOp(v.name)
+			}
+			rhs := p.expr()
+			if _, ok := rhs.(*lambda); isVar && !ok {
+				p.context.ForgetOp(v.name)
+			}
+			if isVar && p.locals != nil {
+				p.locals[v.name] = true
+			}
 			return &binary{
 				left:  lhs,
 				op:    tok.Text,
-				right: p.expr(),
+				right: rhs,
 			}
 		}
 		p.errorf("cannot assign to %s", expr.ProgString())
 	case scan.Operator:
 		p.next()
+		op, fns := tok.Text, p.opValues(tok.Text)
+		if len(op) > 1 && strings.HasSuffix(op, ".") {
+			// Product with an anonymous op on the right.
+			op, fns = p.productLambda(op, fns)
+		}
+		if p.higherOrder(p.peek(), true) {
+			return p.derived(expr, op, fns)
+		}
 		return &binary{
 			left:  expr,
-			op:    tok.Text,
+			op:    op,
+			fns:   fns,
+			right: p.expr(),
+		}
+	case scan.LeftBrace:
+		p.next()
+		op, fns := p.lambdaOp()
+		if p.higherOrder(p.peek(), true) {
+			return p.derived(expr, op, fns)
+		}
+		return &binary{
+			left:  expr,
+			op:    op,
+			fns:   fns,
 			right: p.expr(),
 		}
 	}
@@ -499,20 +591,60 @@ func (p *Parser) expr() value.Expr {
 //	char constant
 //	string constant
 //	vector
+//	anonymous op
+//	tryExpr
 //	operand [ Expr ]...
 //	unop Expr
 func (p *Parser) operand(tok scan.Token, indexOK bool) value.Expr {
 	var expr value.Expr
 	switch tok.Type {
 	case scan.Operator:
+		if p.higherOrder(p.peek(), false) {
+			expr = p.derived(nil, tok.Text, p.opValues(tok.Text))
+			break
+		}
 		expr = &unary{
 			op:    tok.Text,
+			fns:   p.opValues(tok.Text),
+			right: p.expr(),
+		}
+	case scan.LeftBrace:
+		op, fns := p.lambdaOp()
+		if p.higherOrder(p.peek(), false) {
+			expr = p.derived(nil, op, fns)
+			break
+		}
+		if op == "λ1" && !p.startsOperand(p.peek()) {
+			// Not applied, so it is a value.
+			expr = fns[0].expr
+			break
+		}
+		expr = &unary{
+			op:    op,
+			fns:   fns,
 			right: p.expr(),
 		}
 	case scan.Identifier:
+		if p.tryKeyword(tok) {
+			expr = p.tryExpr()
+			break
+		}
+		if p.context.DefinedOp(tok.Text) && p.higherOrder(p.peek(), false) {
+			expr = p.derived(nil, tok.Text, p.opValues(tok.Text))
+			break
+		}
+		if p.context.HigherOrder(tok.Text, false) {
+			p.errorf("%s: missing op operand", tok.Text)
+		}
+		if p.context.OpVariable(tok.Text) && !p.startsOperand(p.peek()) {
+			// An op value not applied to anything, as in an assignment.
+			expr = p.numberOrVector(tok)
+			break
+		}
 		if p.context.DefinedUnary(tok.Text) {
 			expr = &unary{
 				op:    tok.Text,
+				fns:   p.opValues(tok.Text),
 				right: p.expr(),
 			}
 			break
@@ -529,6 +661,16 @@ func (p *Parser) operand(tok scan.Token, indexOK bool) value.Expr {
 	return expr
 }
 
+// startsOperand reports whether the token may begin an operand,
+// so that an op value before it is applied rather than used as a value.
+func (p *Parser) startsOperand(tok scan.Token) bool {
+	switch tok.Type {
+	case scan.Operator, scan.Identifier, scan.Number, scan.Rational, scan.Complex, scan.String, scan.LeftParen, scan.LeftBrace:
+		return true
+	}
+	return false
+}
+
 // index
 //
 //	expr
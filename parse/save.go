@@ -92,10 +92,17 @@ func save(c *exec.Context, file string) {
 		}
 		for _, ref := range references(c, fn.Body) {
 			if !printed[ref] {
+				// A higher-order op must be declared with its op operand.
+				opArg := ""
+				if ref.IsBinary && c.BinaryFn[ref.Name].OpArg != "" {
+					opArg = "(" + c.BinaryFn[ref.Name].OpArg + ") "
+				} else if !ref.IsBinary && c.UnaryFn[ref.Name].OpArg != "" {
+					opArg = "(" + c.UnaryFn[ref.Name].OpArg + ") "
+				}
 				if ref.IsBinary {
-					fmt.Fprintf(out, "op _ %s _\n", ref.Name)
+					fmt.Fprintf(out, "op _ %s%s _\n", opArg, ref.Name)
 				} else {
-					fmt.Fprintf(out, "op %s _\n", ref.Name)
+					fmt.Fprintf(out, "op %s%s _\n", opArg, ref.Name)
 				}
 				printed[ref] = true
 			}
@@ -181,6 +188,8 @@ func put(conf *config.Config, out io.Writer, val value.Value) {
 		// Probably not important but it would be nice to fix it.
 		digits := int(float64(val.Prec()) * 0.301029995664) // 10 log 2.
 		fmt.Fprintf(out, "%.*g", digits+1, val.Float)       // Add another digit to be sure.
+	case value.Float64:
+		fmt.Fprintf(out, "%g", float64(val))
 	case value.Complex:
 		real, imag := val.Components()
 		put(conf, out, real)
@@ -197,6 +206,12 @@ func put(conf *config.Config, out io.Writer, val value.Value) {
 			}
 			put(conf, out, v)
 		}
+	case value.PackedVector:
+		put(conf, out, val.ToVector())
+	case value.Box:
+		fmt.Fprint(out, val.ProgString())
+	case value.Func:
+		putFunc(conf, out, val)
 	case *value.Matrix:
 		put(conf, out, value.NewIntVector(val.Shape()))
 		fmt.Fprint(out, " rho ")
@@ -205,3 +220,23 @@ func put(conf *config.Config, out io.Writer, val value.Value) {
 		value.Errorf("internal error: can't save type %T", val)
 	}
 }
+
+// putFunc writes to out an op value. The values captured by a closure are
+// bound by anonymous ops that assign them, one for each capture, so {x*n}
+// with 3 captured for n is written {n = x; {x * n}} 3.
+func putFunc(conf *config.Config, out io.Writer, fn value.Func) {
+	cl, ok := fn.Op().(*exec.Closure)
+	if !ok || len(cl.Captured) == 0 {
+		fmt.Fprint(out, fn.ProgString())
+		return
+	}
+	names := cl.Fn.Locals[2 : 2+len(cl.Captured)]
+	for _, name := range names {
+		fmt.Fprintf(out, "{%s = x; ", name)
+	}
+	fmt.Fprint(out, fn.ProgString())
+	for i := len(names) - 1; i >= 0; i-- {
+		fmt.Fprint(out, "} ")
+		put(conf, out, cl.Captured[i])
+	}
+}
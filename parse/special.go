@@ -209,6 +209,12 @@ Switch:
 			p.errorf("%v", err)
 		}
 		p.Println("Demo finished")
+	case "float64":
+		if p.peek().Type == scan.EOF {
+			p.Println(truth(conf.Float64()))
+			break Switch
+		}
+		conf.SetFloat64(p.nextDecimalNumber() != 0)
 	case "format":
 		if p.peek().Type == scan.EOF {
 			p.Printf("%q\n", conf.Format())
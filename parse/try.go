@@ -0,0 +1,92 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+import (
+	"fmt"
+	"math/big"
+
+	"robpike.io/ivy/scan"
+	"robpike.io/ivy/value"
+)
+
+// tryExpr is an expression whose errors are trapped: try expr : fallback.
+// If evaluating expr fails, the value is that of the fallback instead.
+// A fallback that is an anonymous op is applied to the error message.
+type tryExpr struct {
+	expr     value.Expr
+	fallback value.Expr
+}
+
+func (t *tryExpr) ProgString() string {
+	return fmt.Sprintf("try %s : %s", t.expr.ProgString(), t.fallback.ProgString())
+}
+
+func (t *tryExpr) Eval(context value.Context) value.Value {
+	v, msg, ok := t.try(context)
+	if ok {
+		return v
+	}
+	if l, isLambda := t.fallback.(*lambda); isLambda {
+		elem := make([]value.Value, 0, len(msg))
+		for _, r := range msg {
+			elem = append(elem, value.Char(r))
+		}
+		return l.Eval(context).(value.Func).EvalUnary(context, value.NewVector(elem))
+	}
+	return t.fallback.Eval(context)
+}
+
+// try evaluates the expression. If it fails, try returns the error
+// message and false.
+func (t *tryExpr) try(context value.Context) (v value.Value, msg string, ok bool) {
+	defer func() {
+		if ok {
+			return
+		}
+		switch err := recover().(type) {
+		case value.Error:
+			msg = err.Error()
+		case big.ErrNaN: // Floating point error from math/big.
+			msg = err.Error()
+		default:
+			panic(err)
+		}
+	}()
+	v = t.expr.Eval(context)
+	return v, "", true
+}
+
+// tryKeyword reports whether the token begins a try expression. The
+// word is a keyword unless it names an op or a variable, including an
+// argument, op operand or local variable of the op being parsed.
+func (p *Parser) tryKeyword(tok scan.Token) bool {
+	if tok.Type != scan.Identifier || tok.Text != "try" || p.peek().Type == scan.Assign {
+		return false
+	}
+	name := tok.Text
+	return !p.context.DefinedOp(name) && p.context.Global(name) == nil &&
+		!p.context.Variable(name) && !p.context.OpArg(name) && !p.locals[name]
+}
+
+// tryExpr parses a try expression. The keyword has been consumed.
+//
+// tryExpr
+//
+//	'try' expr ':' expr
+func (p *Parser) tryExpr() value.Expr {
+	expr := p.expr()
+	if expr == nil {
+		p.errorf("missing expression after try")
+	}
+	if tok := p.next(); tok.Type != scan.Colon {
+		p.errorf("expected colon after try %s, found %s", expr.ProgString(), tok)
+	}
+	fallback := p.expr()
+	if fallback == nil {
+		p.errorf("missing fallback after try %s :", expr.ProgString())
+	}
+	return &tryExpr{expr, fallback}
+}
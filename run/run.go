@@ -152,6 +152,9 @@ func PrintValues(conf *config.Config, writer io.Writer, values []value.Value) bo
 		}
 		fmt.Fprintln(writer)
 	}
+	if conf.JSONOutput() {
+		return printJSON(conf, writer, values)
+	}
 	printed := false
 	for _, v := range values {
 		if _, ok := v.(parse.Assignment); ok {
@@ -170,6 +173,23 @@ func PrintValues(conf *config.Config, writer io.Writer, values []value.Value) bo
 	return printed
 }
 
+// printJSON prints each value as JSON on a line of its own.
+func printJSON(conf *config.Config, writer io.Writer, values []value.Value) bool {
+	printed := false
+	for _, v := range values {
+		if _, ok := v.(parse.Assignment); ok {
+			continue
+		}
+		data, err := value.EncodeJSON(conf, v)
+		if err != nil {
+			value.Errorf("%v", err)
+		}
+		fmt.Fprintf(writer, "%s\n", data)
+		printed = true
+	}
+	return printed
+}
+
 // Ivy evaluates the input string, appending standard output
 // and error output to the provided buffers, which it does by
 // calling context.Config.SetOutput and SetError.
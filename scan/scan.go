@@ -35,6 +35,7 @@ const (
 	Assign     // '='
 	Char       // printable ASCII character; grab bag for comma etc.
 	Identifier // alphanumeric identifier
+	LeftBrace  // '{'
 	LeftBrack  // '['
 	LeftParen  // '('
 	Number     // simple number
@@ -42,6 +43,7 @@ const (
 	Op         // "op", operator definition keyword
 	Rational   // rational number like 2/3
 	Complex    // complex number like 3j2
+	RightBrace // '}', possibly followed by a reduction, scan, product or each
 	RightBrack // ']'
 	RightParen // ')'
 	Semicolon  // ';'
@@ -81,6 +83,9 @@ type Scanner struct {
 	pos       int    // current position in the input
 	start     int    // start position of this item
 	token     Token
+	prev      Type     // type of the previous token
+	opDefn    bool     // in the header of an op definition, before the '='
+	opArgs    []string // op operands declared on this line, as in op (f) name x
 }
 
 // loadLine reads the next line of input and stores it in (appends it to) the input.
@@ -219,12 +224,20 @@ func (l *Scanner) Next() Token {
 	l.lastWidth = 0
 	l.token = Token{EOF, l.pos, "EOF"}
 	state := lexAny
-	for {
+	for state != nil {
 		state = state(l)
-		if state == nil {
-			return l.token
-		}
 	}
+	switch l.token.Type {
+	case Op:
+		l.opDefn = true
+	case Assign:
+		l.opDefn = false
+	case Newline, EOF:
+		l.opDefn = false
+		l.opArgs = nil
+	}
+	l.prev = l.token.Type
+	return l.token
 }
 
 // state functions
@@ -301,6 +314,10 @@ func lexAny(l *Scanner) stateFn {
 		return l.emit(Colon)
 	case r == ']':
 		return l.emit(RightBrack)
+	case r == '{':
+		return l.emit(LeftBrace)
+	case r == '}':
+		return lexRightBrace
 	case r == '(':
 		return l.emit(LeftParen)
 	case r == ')':
@@ -336,12 +353,19 @@ func lexIdentifier(l *Scanner) stateFn {
 	// Some identifiers are operators.
 	word := l.input[l.start:l.pos]
 	switch {
+	case l.opDefn && l.prev == LeftParen:
+		// The op operand of a higher-order op, usable as an operator in its body.
+		l.opArgs = append(l.opArgs, word)
+		return l.emit(Identifier)
 	case word == "op":
 		return l.emit(Op)
 	case word == "o" && l.peek() == '.':
 		return lexOperator
 	case l.defined(word):
 		return lexOperator
+	case l.peek() == '@' && l.context.UserDefined(word, false):
+		// A unary-only user-defined operator applied with each.
+		return lexOperator
 	case isAllDigits(word, l.context.Config().InputBase()):
 		// Mistake: back up and scan it as a number.
 		l.pos = l.start
@@ -351,18 +375,20 @@ func lexIdentifier(l *Scanner) stateFn {
 }
 
 // lexOperator completes scanning an operator. We have already accepted the + or
-// whatever; there may be a reduction or inner or outer product.
+// whatever; there may be a reduction or inner or outer product, and an @ for each.
 func lexOperator(l *Scanner) stateFn {
 	// It might be an inner product or reduction, but only if it is a binary operator.
 	word := l.input[l.start:l.pos]
-	if word == "o" || value.BinaryOps[word] != nil || l.context.UserDefined(word, true) {
+	if word == "o" || value.BinaryOps[word] != nil || l.context.UserDefined(word, true) || l.isOpArg(word) {
 		switch l.peek() {
 		case '/':
-			// Reduction.
+			// Reduction, possibly along the first axis.
 			l.next()
+			l.accept("%")
 		case '\\':
-			// Scan.
+			// Scan, possibly along the first axis.
 			l.next()
+			l.accept("%")
 		case '.':
 			// Inner or outer product?
 			l.next()               // Accept the '.'.
@@ -373,6 +399,10 @@ func lexOperator(l *Scanner) stateFn {
 			prevPos := l.pos
 			r := l.next()
 			switch {
+			case r == '{':
+				// The right operator is an anonymous op.
+				l.backup()
+				return l.emit(Operator)
 			case l.isOperator(r):
 			case isAlphaNumeric(r):
 				for isAlphaNumeric(r) {
@@ -389,12 +419,51 @@ func lexOperator(l *Scanner) stateFn {
 			}
 		}
 	}
+	// Any operator may be applied to each item with @.
+	l.accept("@")
 	if isIdentifier(l.input[l.start:l.pos]) {
 		return l.emit(Identifier)
 	}
 	return l.emit(Operator)
 }
 
+// lexRightBrace scans the end of an anonymous op. It may be followed
+// immediately by a reduction, scan or product, and by an @ for each.
+func lexRightBrace(l *Scanner) stateFn {
+	switch l.peek() {
+	case '/', '\\':
+		l.next()
+		l.accept("%") // First axis.
+	case '.':
+		l.next()
+		if isDigit(l.peek()) { // A number; back up.
+			l.backup()
+			break
+		}
+		prevPos := l.pos
+		r := l.next()
+		switch {
+		case r == '{':
+			// The right operator is another anonymous op.
+			l.backup()
+		case l.isOperator(r):
+		case isAlphaNumeric(r):
+			for isAlphaNumeric(r) {
+				r = l.next()
+			}
+			l.backup()
+			word := l.input[prevPos:l.pos]
+			if !l.defined(word) {
+				return l.errorf("%s not an operator", word)
+			}
+		default:
+			return l.errorf("bad character %#U", r)
+		}
+	}
+	l.accept("@")
+	return l.emit(RightBrace)
+}
+
 // atTerminator reports whether the input is at valid termination character to
 // appear after an identifier or number element.
 func (l *Scanner) atTerminator() bool {
@@ -442,6 +511,8 @@ func acceptNumber(l *Scanner, realPart bool) (bool, stateFn) {
 		// Might be a scan or reduction.
 		if r == '/' || r == '\\' {
 			l.next()
+			l.accept("%") // First axis.
+			l.accept("@") // Each.
 			return false, l.emit(Operator)
 		}
 		if r != '.' && !l.isNumeral(r) {
@@ -699,5 +770,16 @@ func (l *Scanner) isOperator(r rune) bool {
 
 // defined reports whether the argument has been defined as a variable or operator.
 func (l *Scanner) defined(word string) bool {
-	return exec.Predefined(word) || l.context.UserDefined(word, true)
+	return exec.Predefined(word) || l.context.UserDefined(word, true) || l.isOpArg(word)
+}
+
+// isOpArg reports whether the word is the op operand of a higher-order
+// op whose definition began on this line.
+func (l *Scanner) isOpArg(word string) bool {
+	for _, arg := range l.opArgs {
+		if word == arg {
+			return true
+		}
+	}
+	return false
 }
@@ -14,23 +14,25 @@ func _() {
 	_ = x[Assign-3]
 	_ = x[Char-4]
 	_ = x[Identifier-5]
-	_ = x[LeftBrack-6]
-	_ = x[LeftParen-7]
-	_ = x[Number-8]
-	_ = x[Operator-9]
-	_ = x[Op-10]
-	_ = x[Rational-11]
-	_ = x[Complex-12]
-	_ = x[RightBrack-13]
-	_ = x[RightParen-14]
-	_ = x[Semicolon-15]
-	_ = x[String-16]
-	_ = x[Colon-17]
+	_ = x[LeftBrace-6]
+	_ = x[LeftBrack-7]
+	_ = x[LeftParen-8]
+	_ = x[Number-9]
+	_ = x[Operator-10]
+	_ = x[Op-11]
+	_ = x[Rational-12]
+	_ = x[Complex-13]
+	_ = x[RightBrace-14]
+	_ = x[RightBrack-15]
+	_ = x[RightParen-16]
+	_ = x[Semicolon-17]
+	_ = x[String-18]
+	_ = x[Colon-19]
 }
 
-const _Type_name = "EOFErrorNewlineAssignCharIdentifierLeftBrackLeftParenNumberOperatorOpRationalComplexRightBrackRightParenSemicolonStringColon"
+const _Type_name = "EOFErrorNewlineAssignCharIdentifierLeftBraceLeftBrackLeftParenNumberOperatorOpRationalComplexRightBraceRightBrackRightParenSemicolonStringColon"
 
-var _Type_index = [...]uint8{0, 3, 8, 15, 21, 25, 35, 44, 53, 59, 67, 69, 77, 84, 94, 104, 113, 119, 124}
+var _Type_index = [...]uint8{0, 3, 8, 15, 21, 25, 35, 44, 53, 62, 68, 76, 78, 86, 93, 103, 113, 123, 132, 138, 143}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
@@ -0,0 +1,486 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package server provides an HTTP interface to ivy, so programs
+// such as dashboards and notebooks can evaluate ivy expressions without
+// running the interpreter as a subprocess.
+//
+// Each session is an independent execution context with its own
+// variables, ops and configuration. Sessions that are not used for
+// longer than the server's idle timeout are discarded.
+//
+// The API is:
+//
+//	POST   /session                   create a session; returns {"session": id}
+//	DELETE /session/id                delete the session
+//	POST   /session/id/eval           evaluate {"expr": text}; returns {"output": text, "error": text}
+//	GET    /session/id/vars           list the global variables
+//	GET    /session/id/vars/name      fetch a variable; ?format=json (default) or ?format=arrow
+//	                                  (the JSON form of values is that of ivy's json operator)
+//	POST   /session/id/table          load an Arrow IPC stream (default) or, with
+//	                                  ?format=parquet, a Parquet file; each numeric
+//	                                  column becomes a global variable
+//
+// Errors are reported with an appropriate HTTP status and a body of the
+// form {"error": text}.
+//
+// Special commands such as )get and )save are disabled in sessions,
+// since they would give clients access to the server's files. Request
+// bodies larger than MaxBodySize are rejected. An evaluation that runs
+// longer than the server's EvalTimeout stops with an error, and no more
+// than MaxSessions sessions may exist at once.
+package server // import "robpike.io/ivy/server"
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet/file"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+	ivyarrow "robpike.io/ivy/arrow"
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/run"
+	"robpike.io/ivy/value"
+)
+
+// MaxBodySize is the largest request body the server accepts.
+const MaxBodySize = 64 << 20
+
+// Defaults for the limits of a Server.
+const (
+	DefaultEvalTimeout = 30 * time.Second
+	DefaultMaxSessions = 1000
+)
+
+// Server is an http.Handler serving ivy sessions. Its limits may be
+// changed only before it serves any requests.
+type Server struct {
+	// EvalTimeout bounds the time taken by each evaluation;
+	// zero means no limit.
+	EvalTimeout time.Duration
+	// MaxSessions bounds the number of sessions; zero means no limit.
+	MaxSessions int
+
+	setup func(*config.Config)
+	idle  time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	done     chan struct{}
+}
+
+// session is the state of one client's interpreter.
+type session struct {
+	mu       sync.Mutex // Serializes evaluations.
+	conf     config.Config
+	context  *exec.Context
+	lastUsed atomic.Int64 // Time of the last use, in Unix nanoseconds.
+}
+
+// New returns a Server whose sessions are configured by setup, which
+// may be nil, and are discarded after being unused for the idle duration.
+// An idle duration of zero means sessions never expire. The limits on
+// evaluation time and sessions are the defaults.
+func New(setup func(*config.Config), idle time.Duration) *Server {
+	s := &Server{
+		EvalTimeout: DefaultEvalTimeout,
+		MaxSessions: DefaultMaxSessions,
+		setup:       setup,
+		idle:        idle,
+		sessions:    make(map[string]*session),
+		done:        make(chan struct{}),
+	}
+	if idle > 0 {
+		go s.expire()
+	}
+	return s
+}
+
+// Close discards all sessions and stops the expiry of idle sessions.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	close(s.done)
+	for id := range s.sessions {
+		delete(s.sessions, id)
+	}
+}
+
+// expire periodically discards sessions that have been idle too long.
+// It reads the time of last use without locking the session, so it is
+// not held up by a long evaluation.
+func (s *Server) expire() {
+	ticker := time.NewTicker(s.idle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for id, sess := range s.sessions {
+				if now.Sub(sess.used()) > s.idle {
+					delete(s.sessions, id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// used returns the time the session was last used.
+func (sess *session) used() time.Time {
+	return time.Unix(0, sess.lastUsed.Load())
+}
+
+// use records that the session is being used.
+func (sess *session) use() {
+	sess.lastUsed.Store(time.Now().UnixNano())
+}
+
+// errTooManySessions is returned by newSession when the server
+// has MaxSessions sessions.
+var errTooManySessions = errors.New("too many sessions")
+
+// newSession creates and registers a new session, returning its id.
+func (s *Server) newSession() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf[:])
+	sess := new(session)
+	sess.use()
+	if s.setup != nil {
+		s.setup(&sess.conf)
+	}
+	sess.conf.SetSpecialCommands(false)
+	sess.context = exec.NewContext(&sess.conf).(*exec.Context)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.MaxSessions > 0 && len(s.sessions) >= s.MaxSessions {
+		return "", errTooManySessions
+	}
+	s.sessions[id] = sess
+	return id, nil
+}
+
+// lookup returns the session with the given id, locked, or nil if there is none.
+// The caller must unlock the session.
+func (s *Server) lookup(id string) *session {
+	s.mu.Lock()
+	sess := s.sessions[id]
+	s.mu.Unlock()
+	if sess == nil {
+		return nil
+	}
+	sess.mu.Lock()
+	sess.use()
+	return sess
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if path[0] != "session" {
+		httpError(w, http.StatusNotFound, "no such endpoint %q", r.URL.Path)
+		return
+	}
+	if len(path) == 1 {
+		if r.Method != http.MethodPost {
+			httpError(w, http.StatusMethodNotAllowed, "%s not allowed", r.Method)
+			return
+		}
+		id, err := s.newSession()
+		if err == errTooManySessions {
+			httpError(w, http.StatusServiceUnavailable, "%v", err)
+			return
+		}
+		if err != nil {
+			httpError(w, http.StatusInternalServerError, "%v", err)
+			return
+		}
+		writeJSON(w, map[string]string{"session": id})
+		return
+	}
+	id := path[1]
+	if len(path) == 2 && r.Method == http.MethodDelete {
+		s.mu.Lock()
+		_, ok := s.sessions[id]
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		if !ok {
+			httpError(w, http.StatusNotFound, "no session %q", id)
+		}
+		return
+	}
+	sess := s.lookup(id)
+	if sess == nil {
+		httpError(w, http.StatusNotFound, "no session %q", id)
+		return
+	}
+	defer sess.mu.Unlock()
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+	switch {
+	case len(path) == 3 && path[2] == "eval" && r.Method == http.MethodPost:
+		sess.eval(w, r, s.EvalTimeout)
+	case len(path) == 3 && path[2] == "vars" && r.Method == http.MethodGet:
+		sess.vars(w)
+	case len(path) == 4 && path[2] == "vars" && r.Method == http.MethodGet:
+		sess.fetch(w, path[3], r.URL.Query().Get("format"))
+	case len(path) == 3 && path[2] == "table" && r.Method == http.MethodPost:
+		sess.table(w, r)
+	default:
+		httpError(w, http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+	}
+}
+
+// eval evaluates the expression in the request, stopping with an
+// error if that takes longer than the timeout, if it is not zero.
+func (sess *session) eval(w http.ResponseWriter, r *http.Request, timeout time.Duration) {
+	var req struct {
+		Expr string `json:"expr"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, bodyStatus(err), "bad request: %v", err)
+		return
+	}
+	if timeout > 0 {
+		sess.context.SetDeadline(time.Now().Add(timeout))
+		defer sess.context.SetDeadline(time.Time{})
+	}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	run.Ivy(sess.context, req.Expr, stdout, stderr)
+	writeJSON(w, map[string]string{
+		"output": stdout.String(),
+		"error":  stderr.String(),
+	})
+}
+
+// variable describes a global variable in a vars listing.
+type variable struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Shape []int  `json:"shape"`
+}
+
+// vars lists the global variables of the session.
+func (sess *session) vars(w http.ResponseWriter) {
+	vars := []variable{}
+	for name, v := range sess.context.Globals {
+		vars = append(vars, variable{name, typeName(v), shape(v)})
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+	writeJSON(w, map[string]interface{}{"vars": vars})
+}
+
+// fetch writes the named variable in the requested format.
+func (sess *session) fetch(w http.ResponseWriter, name, format string) {
+	v := sess.context.Global(name)
+	if v == nil {
+		httpError(w, http.StatusNotFound, "no variable %q", name)
+		return
+	}
+	switch format {
+	case "", "json":
+		data, err := value.EncodeJSON(&sess.conf, v)
+		if err != nil {
+			httpError(w, http.StatusBadRequest, "%s: %v", name, err)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"name": name, "value": json.RawMessage(data)})
+	case "arrow":
+		var buf bytes.Buffer
+		if err := writeArrow(&buf, name, v); err != nil {
+			httpError(w, http.StatusBadRequest, "%s: %v", name, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.apache.arrow.stream")
+		w.Write(buf.Bytes())
+	default:
+		httpError(w, http.StatusBadRequest, "unknown format %q", format)
+	}
+}
+
+// table loads the table in the request body into the session's globals.
+func (sess *session) table(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpError(w, bodyStatus(err), "%v", err)
+		return
+	}
+	var table arrow.Table
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "arrow":
+		table, err = readArrow(body)
+	case "parquet":
+		table, err = readParquet(body)
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	defer table.Release()
+	if err := sess.context.LoadGlobalsFromTable(table, &sess.conf, nil); err != nil {
+		httpError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	names := []string{}
+	for _, f := range table.Schema().Fields() {
+		if _, ok := sess.context.Global(f.Name).(value.ArrowVector); ok {
+			names = append(names, f.Name)
+		}
+	}
+	writeJSON(w, map[string]interface{}{"vars": names})
+}
+
+// readArrow reads a table from an Arrow IPC stream.
+func readArrow(data []byte) (arrow.Table, error) {
+	rdr, err := ipc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Release()
+	var recs []arrow.Record
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	for rdr.Next() {
+		rec := rdr.Record()
+		rec.Retain()
+		recs = append(recs, rec)
+	}
+	if err := rdr.Err(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return array.NewTableFromRecords(rdr.Schema(), recs), nil
+}
+
+// readParquet reads a table from the contents of a Parquet file.
+func readParquet(data []byte) (arrow.Table, error) {
+	pf, err := file.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer pf.Close()
+	reader, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return nil, err
+	}
+	return reader.ReadTable(context.Background())
+}
+
+// writeArrow writes the value as an Arrow IPC stream holding a single
+// column with the given name.
+func writeArrow(w io.Writer, name string, v value.Value) error {
+	schema, recs, err := ivyarrow.Records(name, v, memory.DefaultAllocator)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, rec := range recs {
+			rec.Release()
+		}
+	}()
+	wr := ipc.NewWriter(w, ipc.WithSchema(schema))
+	for _, rec := range recs {
+		if err := wr.Write(rec); err != nil {
+			return err
+		}
+	}
+	return wr.Close()
+}
+
+// typeName returns the name of the type of the value for a vars listing.
+func typeName(v value.Value) string {
+	switch v.(type) {
+	case value.Int, value.BigInt:
+		return "int"
+	case value.BigRat:
+		return "rational"
+	case value.BigFloat, value.Float64:
+		return "float"
+	case value.Complex:
+		return "complex"
+	case value.Char:
+		return "char"
+	case value.Box:
+		return "box"
+	case value.Func:
+		return "op"
+	case value.Vector, value.PackedVector:
+		return "vector"
+	case value.ArrowVector:
+		return "arrow"
+	case *value.Matrix:
+		return "matrix"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// shape returns the shape of the value.
+func shape(v value.Value) []int {
+	switch v := v.(type) {
+	case value.Vector:
+		return []int{v.Len()}
+	case value.PackedVector:
+		return []int{v.Len()}
+	case value.ArrowVector:
+		return []int{v.Len()}
+	case *value.Matrix:
+		return v.Shape()
+	}
+	return []int{}
+}
+
+// writeJSON writes the JSON encoding of x as the response.
+func writeJSON(w http.ResponseWriter, x interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(x)
+}
+
+// bodyStatus returns the HTTP status for an error reading a request body.
+func bodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// httpError writes a JSON error response with the given status.
+func httpError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf(format, args...)})
+}
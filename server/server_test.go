@@ -0,0 +1,322 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"robpike.io/ivy/config"
+)
+
+// call makes an HTTP request and decodes the JSON response into result.
+func call(t *testing.T, ts *httptest.Server, method, path string, body io.Reader, result interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func newSession(t *testing.T, ts *httptest.Server) string {
+	t.Helper()
+	var r struct{ Session string }
+	if status := call(t, ts, "POST", "/session", nil, &r); status != http.StatusOK {
+		t.Fatalf("create session: status %d", status)
+	}
+	return r.Session
+}
+
+func eval(t *testing.T, ts *httptest.Server, id, expr string) (output, errors string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"expr": expr})
+	var r struct{ Output, Error string }
+	if status := call(t, ts, "POST", "/session/"+id+"/eval", bytes.NewReader(body), &r); status != http.StatusOK {
+		t.Fatalf("eval %q: status %d", expr, status)
+	}
+	return r.Output, r.Error
+}
+
+func TestEval(t *testing.T) {
+	srv := New(nil, 0)
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	a := newSession(t, ts)
+	b := newSession(t, ts)
+	if out, errs := eval(t, ts, a, "x = iota 3; x * 2"); out != "2 4 6\n" || errs != "" {
+		t.Errorf("eval: got %q, %q", out, errs)
+	}
+	if _, errs := eval(t, ts, b, "x"); errs == "" {
+		t.Errorf("x defined in second session")
+	}
+	if _, errs := eval(t, ts, a, "1/0"); errs == "" {
+		t.Errorf("no error for 1/0")
+	}
+
+	for _, cmd := range []string{")save \"/tmp/ivy.save\"", ")get \"/etc/passwd\"", ")demo"} {
+		if _, errs := eval(t, ts, a, cmd); !strings.Contains(errs, "special commands are disabled") {
+			t.Errorf("%s: got error %q", cmd, errs)
+		}
+	}
+	big := bytes.Repeat([]byte(" "), MaxBodySize+1)
+	if status := call(t, ts, "POST", "/session/"+a+"/table", bytes.NewReader(big), nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized table: status %d", status)
+	}
+
+	eval(t, ts, a, "m = 2 2 rho 1 2 3 4; r = 1/3")
+	var vars struct {
+		Vars []variable
+	}
+	call(t, ts, "GET", "/session/"+a+"/vars", nil, &vars)
+	var names []string
+	for _, v := range vars.Vars {
+		names = append(names, v.Name)
+	}
+	if want := []string{"_", "e", "m", "pi", "r", "x"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("vars: got %v; want %v", names, want)
+	}
+
+	var fetched struct {
+		Name  string
+		Value interface{}
+	}
+	call(t, ts, "GET", "/session/"+a+"/vars/x", nil, &fetched)
+	if want := []interface{}{1.0, 2.0, 3.0}; !reflect.DeepEqual(fetched.Value, want) {
+		t.Errorf("fetch x: got %v; want %v", fetched.Value, want)
+	}
+	call(t, ts, "GET", "/session/"+a+"/vars/m", nil, &fetched)
+	want := map[string]interface{}{
+		"shape": []interface{}{2.0, 2.0},
+		"data":  []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0, 4.0}},
+	}
+	if !reflect.DeepEqual(fetched.Value, want) {
+		t.Errorf("fetch m: got %v; want %v", fetched.Value, want)
+	}
+	call(t, ts, "GET", "/session/"+a+"/vars/r", nil, &fetched)
+	if want := map[string]interface{}{"rat": "1/3"}; !reflect.DeepEqual(fetched.Value, want) {
+		t.Errorf("fetch r: got %v; want %v", fetched.Value, want)
+	}
+	if status := call(t, ts, "GET", "/session/"+a+"/vars/nosuch", nil, nil); status != http.StatusNotFound {
+		t.Errorf("fetch of missing variable: status %d", status)
+	}
+
+	if status := call(t, ts, "DELETE", "/session/"+a, nil, nil); status != http.StatusOK {
+		t.Errorf("delete: status %d", status)
+	}
+	if status := call(t, ts, "GET", "/session/"+a+"/vars", nil, nil); status != http.StatusNotFound {
+		t.Errorf("deleted session still present: status %d", status)
+	}
+}
+
+func TestArrow(t *testing.T) {
+	srv := New(nil, 0)
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	id := newSession(t, ts)
+
+	// Upload a table with two chunks.
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "price", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "qty", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	for _, chunk := range [][2][]int64{{{1, 2}, {10, 20}}, {{3}, {30}}} {
+		b.Field(0).(*array.Int64Builder).AppendValues(chunk[0], nil)
+		b.Field(1).(*array.Int64Builder).AppendValues(chunk[1], nil)
+		rec := b.NewRecord()
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+		rec.Release()
+	}
+	w.Close()
+	var loaded struct{ Vars []string }
+	if status := call(t, ts, "POST", "/session/"+id+"/table", &buf, &loaded); status != http.StatusOK {
+		t.Fatalf("upload: status %d", status)
+	}
+	if want := []string{"price", "qty"}; !reflect.DeepEqual(loaded.Vars, want) {
+		t.Errorf("upload: loaded %v; want %v", loaded.Vars, want)
+	}
+	if out, errs := eval(t, ts, id, "total = price * qty; total"); out != "10 40 90\n" || errs != "" {
+		t.Errorf("eval: got %q, %q", out, errs)
+	}
+
+	// Binary ops with columns as operands.
+	for _, test := range []struct{ expr, want string }{
+		{"price + 1", "2 3 4\n"},
+		{"1 + price", "2 3 4\n"},
+		{"price + qty", "11 22 33\n"},
+		{"10 20 30 - price", "9 18 27\n"},
+		{"price == 2", "0 1 0\n"},
+		{"price max qty", "10 20 30\n"},
+		{"-2 take price", "2 3\n"},
+		{"2 drop price", "3\n"},
+		{"price , qty", "1 2 3 10 20 30\n"},
+		{"price in 2 5", "0 1 0\n"},
+		{"1 2 bin price", "1 2 2\n"},
+		{"price cov qty", "10\n"},
+		{"2 +/ price", "3 5\n"},
+		{"qty mdiv price", "1/10\n"},
+		{"price +.* qty", "140\n"},
+		{"price o.* 1 2", "1 2\n2 4\n3 6\n"},
+	} {
+		if out, errs := eval(t, ts, id, test.expr); out != test.want || errs != "" {
+			t.Errorf("%s: got %q, %q; want %q", test.expr, out, errs, test.want)
+		}
+	}
+
+	// Fetch the result back as Arrow.
+	req, _ := http.NewRequest("GET", ts.URL+"/session/"+id+"/vars/total?format=arrow", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	rdr, err := ipc.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rdr.Release()
+	if name := rdr.Schema().Field(0).Name; name != "total" {
+		t.Errorf("column name %q; want total", name)
+	}
+	var got []int64
+	for rdr.Next() {
+		got = append(got, rdr.Record().Column(0).(*array.Int64).Int64Values()...)
+	}
+	if want := []int64{10, 40, 90}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fetch arrow: got %v; want %v", got, want)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	srv := New(nil, 20*time.Millisecond)
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	id := newSession(t, ts)
+	time.Sleep(100 * time.Millisecond)
+	if status := call(t, ts, "GET", "/session/"+id+"/vars", nil, nil); status != http.StatusNotFound {
+		t.Errorf("idle session not expired: status %d", status)
+	}
+}
+
+// loop is a program that runs forever unless stopped.
+const loop = "op forever x =\n :while 1\n :end\n x\n\nforever 1"
+
+func TestEvalTimeout(t *testing.T) {
+	srv := New(nil, 0)
+	srv.EvalTimeout = 50 * time.Millisecond
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	id := newSession(t, ts)
+	if _, errs := eval(t, ts, id, loop); !strings.Contains(errs, "time limit exceeded") {
+		t.Errorf("endless loop: got error %q; want time limit exceeded", errs)
+	}
+	// The next evaluation has its own time limit.
+	if out, errs := eval(t, ts, id, "op f x = x + 1\nf 1"); out != "2\n" || errs != "" {
+		t.Errorf("eval after timeout: got %q, %q", out, errs)
+	}
+}
+
+func TestMaxSessions(t *testing.T) {
+	srv := New(nil, 0)
+	srv.MaxSessions = 2
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	id := newSession(t, ts)
+	newSession(t, ts)
+	if status := call(t, ts, "POST", "/session", nil, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("session beyond limit: status %d; want %d", status, http.StatusServiceUnavailable)
+	}
+	call(t, ts, "DELETE", "/session/"+id, nil, nil)
+	newSession(t, ts)
+}
+
+// A long evaluation in one session does not hold up the others,
+// even while idle sessions are being expired.
+func TestLongEval(t *testing.T) {
+	srv := New(nil, 200*time.Millisecond)
+	srv.EvalTimeout = 2 * time.Second
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	busy := newSession(t, ts)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		body, _ := json.Marshal(map[string]string{"expr": loop})
+		resp, err := http.Post(ts.URL+"/session/"+busy+"/eval", "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	// Let the busy session become due for expiry.
+	time.Sleep(300 * time.Millisecond)
+	start := time.Now()
+	id := newSession(t, ts)
+	if out, _ := eval(t, ts, id, "1 + 1"); out != "2\n" {
+		t.Errorf("eval during long eval: got %q", out)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("eval during long eval took %v", d)
+	}
+	<-done
+}
+
+func TestConcurrentSessions(t *testing.T) {
+	srv := New(func(conf *config.Config) { conf.SetFloatPrec(100) }, 0)
+	defer srv.Close()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	ids := make([]string, 4)
+	for i := range ids {
+		ids[i] = newSession(t, ts)
+	}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				if out, errs := eval(t, ts, id, "floor 1e6 * sin pi/6"); out != "500000\n" || errs != "" {
+					t.Errorf("eval: got %q, %q", out, errs)
+				}
+			}
+		}(id)
+	}
+	wg.Wait()
+}
@@ -92,14 +92,23 @@ func (v ArrowVector) AllChars() bool {
 }
 
 // AllInts reports whether the vector contains only Ints.
+// Get converts every integer column type to Int, so only the column type matters.
 func (v ArrowVector) AllInts() bool {
-	return true
-	for i := 0; i < v.resolver.NumRows(); i++ {
-		if _, ok := v.Get(i).(Int); !ok {
-			return false
-		}
+	switch v.col.DataType() {
+	case arrow.PrimitiveTypes.Int8, arrow.PrimitiveTypes.Int16, arrow.PrimitiveTypes.Int32, arrow.PrimitiveTypes.Int64,
+		arrow.PrimitiveTypes.Uint8, arrow.PrimitiveTypes.Uint16, arrow.PrimitiveTypes.Uint32, arrow.PrimitiveTypes.Uint64:
+		return true
 	}
-	return true
+	return false
+}
+
+// isFloat reports whether v is a floating-point column.
+func (v ArrowVector) isFloat() bool {
+	switch v.col.DataType() {
+	case arrow.PrimitiveTypes.Float32, arrow.PrimitiveTypes.Float64:
+		return true
+	}
+	return false
 }
 
 // func NewArrowVector(elems []Value) ArrowVector {
@@ -150,15 +159,88 @@ func (v ArrowVector) Get(i int) Value {
 		return Int(x[offset])
 	case arrow.PrimitiveTypes.Float32:
 		x := v.col.Data().Chunk(c).(*array.Float32).Float32Values()
-		return BigFloat{new(big.Float).SetPrec(v.config.FloatPrec()).SetFloat64(float64(x[offset]))}
+		return v.newFloat(float64(x[offset]))
 	case arrow.PrimitiveTypes.Float64:
 		x := v.col.Data().Chunk(c).(*array.Float64).Float64Values()
-		return BigFloat{new(big.Float).SetPrec(v.config.FloatPrec()).SetFloat64(x[offset])}
+		return v.newFloat(x[offset])
 	}
 	vprint.VV("Get value not supported returning nil %v", v.col.DataType())
 	return nil
 }
 
+// int64s returns the elements of v, if it is an integer column,
+// without making a Value for each.
+func (v ArrowVector) int64s() ([]int64, bool) {
+	if !v.AllInts() {
+		return nil, false
+	}
+	x := make([]int64, 0, v.Len())
+	for _, chunk := range v.col.Data().Chunks() {
+		switch a := chunk.(type) {
+		case *array.Int8:
+			for _, e := range a.Int8Values() {
+				x = append(x, int64(e))
+			}
+		case *array.Int16:
+			for _, e := range a.Int16Values() {
+				x = append(x, int64(e))
+			}
+		case *array.Int32:
+			for _, e := range a.Int32Values() {
+				x = append(x, int64(e))
+			}
+		case *array.Int64:
+			x = append(x, a.Int64Values()...)
+		case *array.Uint8:
+			for _, e := range a.Uint8Values() {
+				x = append(x, int64(e))
+			}
+		case *array.Uint16:
+			for _, e := range a.Uint16Values() {
+				x = append(x, int64(e))
+			}
+		case *array.Uint32:
+			for _, e := range a.Uint32Values() {
+				x = append(x, int64(e))
+			}
+		case *array.Uint64:
+			for _, e := range a.Uint64Values() {
+				x = append(x, int64(e))
+			}
+		}
+	}
+	return x, true
+}
+
+// float64s returns the elements of v, if it is a floating-point column,
+// without making a Value for each.
+func (v ArrowVector) float64s() ([]float64, bool) {
+	if !v.isFloat() {
+		return nil, false
+	}
+	x := make([]float64, 0, v.Len())
+	for _, chunk := range v.col.Data().Chunks() {
+		switch a := chunk.(type) {
+		case *array.Float32:
+			for _, e := range a.Float32Values() {
+				x = append(x, float64(e))
+			}
+		case *array.Float64:
+			x = append(x, a.Float64Values()...)
+		}
+	}
+	return x, true
+}
+
+// newFloat returns the Value for an element of a floating-point column,
+// as Get does: a Float64 in float64 mode, otherwise a BigFloat.
+func (v ArrowVector) newFloat(x float64) Value {
+	if v.config.Float64() {
+		return Float64(x)
+	}
+	return BigFloat{new(big.Float).SetPrec(v.config.FloatPrec()).SetFloat64(x)}
+}
+
 func (v ArrowVector) Eval(Context) Value {
 	return v
 }
@@ -180,10 +262,14 @@ func (v ArrowVector) ToVector() Vector {
 	return NewVector(elem)
 }
 
+// toType converts v. Asked for an arrowVectorType, it returns the
+// column itself, so that the implementations of binary ops for
+// columns, such as that of bin, can read it directly; those for
+// elementwise ops and the others read it through Get.
 func (v ArrowVector) toType(op string, conf *config.Config, which valueType) Value {
 	switch which {
 	case arrowVectorType:
-		return v.ToVector()
+		return v
 	case vectorType:
 		return v.ToVector()
 	case matrixType:
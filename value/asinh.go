@@ -38,6 +38,15 @@ func atanh(c Context, v Value) Value {
 		}
 		v = u.real
 	}
+	if c.Config().Float64() {
+		// As in IEEE arithmetic, atanh ±1 is ±Inf.
+		if compare(v, 1) == 0 {
+			return BigFloat{newFloat(c).SetInf(false)}
+		}
+		if compare(v, -1) == 0 {
+			return BigFloat{newFloat(c).SetInf(true)}
+		}
+	}
 	if compare(v, -1) <= 0 || 0 <= compare(v, 1) {
 		return complexAtanh(c, newComplex(v, zero))
 	}
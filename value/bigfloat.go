@@ -66,8 +66,8 @@ func (f BigFloat) Sprint(conf *config.Config) string {
 			eChar = 'E'
 		}
 		fexp := newF(conf).SetInt64(int64(exp))
-		fexp.Mul(fexp, floatLog2)
-		fexp.Quo(fexp, floatLog10)
+		fexp.Mul(fexp, constsFor(conf).log2)
+		fexp.Quo(fexp, constsFor(conf).log10)
 		// We now have a floating-point base 10 exponent.
 		// Break into the integer part and the fractional part.
 		// The integer part is what we will show.
@@ -76,7 +76,7 @@ func (f BigFloat) Sprint(conf *config.Config) string {
 		fraction := fexp.Sub(fexp, newF(conf).SetInt(iexp))
 		// Now compute 10**(fractional part).
 		// Fraction is in base 10. Move it to base e.
-		fraction.Mul(fraction, floatLog10)
+		fraction.Mul(fraction, constsFor(conf).log10)
 		scale := exponential(conf, fraction)
 		if positive > 0 {
 			mant.Mul(&mant, scale)
@@ -139,14 +139,19 @@ func (f BigFloat) toType(op string, conf *config.Config, which valueType) Value
 	switch which {
 	case bigFloatType:
 		return f
+	case float64Type:
+		x, _ := f.Float64()
+		return Float64(x)
 	case complexType:
 		return newComplex(f, Int(0))
-	case vectorType:
+	case vectorType, packedVectorType:
 		return NewVector([]Value{f})
 	case arrowVectorType:
 		return NewVector([]Value{f})
 	case matrixType:
 		return NewMatrix([]int{1}, []Value{f})
+	case boxType:
+		return f
 	}
 	Errorf("%s: cannot convert float to %s", op, which)
 	return nil
@@ -148,12 +148,17 @@ func (i BigInt) toType(op string, conf *config.Config, which valueType) Value {
 	case bigFloatType:
 		f := new(big.Float).SetPrec(conf.FloatPrec()).SetInt(i.Int)
 		return BigFloat{f}
+	case float64Type:
+		f, _ := new(big.Float).SetInt(i.Int).Float64()
+		return Float64(f)
 	case complexType:
 		return newComplex(i, Int(0))
-	case vectorType:
+	case vectorType, packedVectorType:
 		return NewVector([]Value{i})
 	case matrixType:
 		return NewMatrix([]int{1}, []Value{i})
+	case boxType:
+		return i
 	}
 	Errorf("%s: cannot convert big int to %s", op, which)
 	return nil
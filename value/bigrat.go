@@ -178,12 +178,17 @@ func (r BigRat) toType(op string, conf *config.Config, which valueType) Value {
 	case bigFloatType:
 		f := new(big.Float).SetPrec(conf.FloatPrec()).SetRat(r.Rat)
 		return BigFloat{f}
+	case float64Type:
+		f, _ := r.Float64()
+		return Float64(f)
 	case complexType:
 		return newComplex(r, Int(0))
-	case vectorType:
+	case vectorType, packedVectorType:
 		return NewVector([]Value{r})
 	case matrixType:
 		return NewMatrix([]int{1, 1}, []Value{r})
+	case boxType:
+		return r
 	}
 	Errorf("%s: cannot convert rational to %s", op, which)
 	return nil
@@ -60,17 +60,47 @@ func vectorAndMatrixType(t1, t2 valueType) (valueType, valueType) {
 }
 
 // vectorAndAtLeastVectorType promotes the left arg to vector
-// and the right arg to at least vector.
+// and the right arg to at least vector. A packed left arg stays packed.
 func vectorAndAtLeastVectorType(t1, t2 valueType) (valueType, valueType) {
+	if t1 != packedVectorType {
+		t1 = vectorType
+	}
 	if t2 < vectorType {
 		t2 = vectorType
 	}
 	if t2 == arrowVectorType {
 		t2 = vectorType
 	}
+	return t1, t2
+}
+
+// vectorAndAnyType promotes the left arg to vector
+// and leaves the right arg alone.
+func vectorAndAnyType(t1, t2 valueType) (valueType, valueType) {
 	return vectorType, t2
 }
 
+// sampleType promotes scalar arguments to vectors and leaves arrays,
+// including Arrow columns, alone.
+func sampleType(t1, t2 valueType) (valueType, valueType) {
+	if t1 < vectorType {
+		t1 = vectorType
+	}
+	if t2 < vectorType {
+		t2 = vectorType
+	}
+	return t1, t2
+}
+
+// anyAndSampleType leaves the left arg alone and promotes
+// a scalar right arg to vector.
+func anyAndSampleType(t1, t2 valueType) (valueType, valueType) {
+	if t2 < vectorType {
+		t2 = vectorType
+	}
+	return t1, t2
+}
+
 // shiftCount converts x to an unsigned integer.
 func shiftCount(x Value) uint {
 	switch count := x.(type) {
@@ -173,6 +203,8 @@ func toBool(t Value) bool {
 		return t.Sign() != 0
 	case BigFloat:
 		return t.Sign() != 0
+	case Float64:
+		return t != 0
 	case Complex:
 		return !isZero(t.real) || !isZero(t.imag)
 	}
@@ -181,8 +213,12 @@ func toBool(t Value) bool {
 }
 
 // andBool is like toBool but handles vectors by and'ing the values together.
-// The results are known to be Ints, as they come from comparison operations.
+// The results are known to be Ints, as they come from comparison operations,
+// or a packed vector of them.
 func andBool(t Value) bool {
+	if p, ok := t.(PackedVector); ok {
+		t = p.ToVector()
+	}
 	if v, ok := t.(Vector); ok {
 		for _, x := range v {
 			if x == Int(0) {
@@ -888,6 +924,102 @@ func init() {
 			},
 		},
 
+		{
+			name:      "uniform",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "uniform", u, 1, 2)
+					if len(p) == 1 {
+						return uniform(c, 0, p[0], v)
+					}
+					return uniform(c, p[0], p[1], v)
+				},
+				vectorType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "uniform", u, 1, 2)
+					if len(p) == 1 {
+						return uniform(c, 0, p[0], v)
+					}
+					return uniform(c, p[0], p[1], v)
+				},
+			},
+		},
+
+		{
+			name:      "normal",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "normal", u, 1, 2)
+					if len(p) == 1 {
+						return normal(c, p[0], 1, v)
+					}
+					return normal(c, p[0], p[1], v)
+				},
+				vectorType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "normal", u, 1, 2)
+					if len(p) == 1 {
+						return normal(c, p[0], 1, v)
+					}
+					return normal(c, p[0], p[1], v)
+				},
+			},
+		},
+
+		{
+			name:      "expo",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "expo", u, 1, 1)
+					return expo(c, p[0], v)
+				},
+				vectorType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "expo", u, 1, 1)
+					return expo(c, p[0], v)
+				},
+			},
+		},
+
+		{
+			name:      "poisson",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "poisson", u, 1, 1)
+					return poisson(c, p[0], v)
+				},
+				vectorType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "poisson", u, 1, 1)
+					return poisson(c, p[0], v)
+				},
+			},
+		},
+
+		{
+			name:      "binomial",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "binomial", u, 2, 2)
+					return binomial(c, p[0], p[1], v)
+				},
+				vectorType: func(c Context, u, v Value) Value {
+					p := randomParams(c, "binomial", u, 2, 2)
+					return binomial(c, p[0], p[1], v)
+				},
+			},
+		},
+
+		{
+			name:      "sample",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType:    weightedSample,
+				vectorType: weightedSample,
+			},
+		},
+
 		{
 			name:      "decode",
 			whichType: vectorAndAtLeastVectorType,
@@ -1060,6 +1192,100 @@ func init() {
 			},
 		},
 
+		{
+			name:      "bin",
+			whichType: vectorAndAnyType,
+			fn: [numType]binaryFn{
+				intType:         binOp,
+				charType:        binOp,
+				bigIntType:      binOp,
+				bigRatType:      binOp,
+				bigFloatType:    binOp,
+				vectorType:      binOp,
+				arrowVectorType: binOp,
+				matrixType:      binOp,
+			},
+		},
+
+		{
+			name:      "window",
+			whichType: anyAndSampleType,
+			fn: [numType]binaryFn{
+				vectorType:      window,
+				arrowVectorType: window,
+			},
+		},
+
+		{
+			name:      "cov",
+			whichType: sampleType,
+			fn: [numType]binaryFn{
+				vectorType:      binaryStatOp("cov", cov),
+				arrowVectorType: binaryStatOp("cov", cov),
+				matrixType:      binaryStatOp("cov", cov),
+			},
+		},
+
+		{
+			name:      "corr",
+			whichType: sampleType,
+			fn: [numType]binaryFn{
+				vectorType:      binaryStatOp("corr", corr),
+				arrowVectorType: binaryStatOp("corr", corr),
+				matrixType:      binaryStatOp("corr", corr),
+			},
+		},
+
+		{
+			name:      "quantile",
+			whichType: anyAndSampleType,
+			fn: [numType]binaryFn{
+				vectorType:      quantileOp("quantile", 1),
+				arrowVectorType: quantileOp("quantile", 1),
+				matrixType:      quantileOp("quantile", 1),
+			},
+		},
+
+		{
+			name:      "percentile",
+			whichType: anyAndSampleType,
+			fn: [numType]binaryFn{
+				vectorType:      quantileOp("percentile", 100),
+				arrowVectorType: quantileOp("percentile", 100),
+				matrixType:      quantileOp("percentile", 100),
+			},
+		},
+
+		{
+			name:      "union",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					return union(u.(Vector), v.(Vector))
+				},
+			},
+		},
+
+		{
+			name:      "intersect",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					return NewVector(selectIn(u.(Vector), keySet(v.(Vector)), true))
+				},
+			},
+		},
+
+		{
+			name:      "without",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					return NewVector(selectIn(u.(Vector), keySet(v.(Vector)), false))
+				},
+			},
+		},
+
 		{
 			name:      "iota",
 			whichType: atLeastVectorType,
@@ -1352,6 +1578,21 @@ func init() {
 			},
 		},
 
+		{
+			name:      "mdiv",
+			whichType: noPromoteType,
+			fn: [numType]binaryFn{
+				intType:         matrixDivide,
+				bigIntType:      matrixDivide,
+				bigRatType:      matrixDivide,
+				bigFloatType:    matrixDivide,
+				complexType:     matrixDivide,
+				vectorType:      matrixDivide,
+				arrowVectorType: matrixDivide,
+				matrixType:      matrixDivide,
+			},
+		},
+
 		{
 			name:      "rot",
 			whichType: atLeastVectorType,
@@ -1521,6 +1762,12 @@ func init() {
 					}
 					return NewVector(result)
 				},
+				packedVectorType: func(c Context, u, v Value) Value {
+					if w, ok := bitSel(u, v); ok {
+						return w
+					}
+					return c.EvalBinary(unpack(u), "sel", unpack(v))
+				},
 				matrixType: func(c Context, u, v Value) Value {
 					return v.(*Matrix).sel(c, u.(Vector))
 				},
@@ -1553,6 +1800,7 @@ func init() {
 	}
 
 	for _, op := range ops {
+		op.fn[float64Type] = float64Binary(op.name, op.fn[bigFloatType])
 		BinaryOps[op.name] = op
 	}
 }
@@ -0,0 +1,193 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "math/bits"
+
+// A bitVector holds n truth values packed 64 to a word, the first
+// value in the lowest bit of the first word. The bits of the last word
+// beyond n are always zero, so words can be counted and compared
+// without masking.
+type bitVector struct {
+	words []uint64
+	n     int
+}
+
+// newBitVector returns the bitVector of length n whose ith bit is test(i).
+func newBitVector(n int, test func(i int) bool) bitVector {
+	b := bitVector{words: make([]uint64, (n+63)/64), n: n}
+	// Each word is built by one goroutine, so there are no races on words.
+	pfor(true, 64, len(b.words), func(lo, hi int) {
+		for w := lo; w < hi; w++ {
+			var word uint64
+			end := (w + 1) * 64
+			if end > n {
+				end = n
+			}
+			for i := w * 64; i < end; i++ {
+				if test(i) {
+					word |= 1 << (i % 64)
+				}
+			}
+			b.words[w] = word
+		}
+	})
+	return b
+}
+
+// get returns the ith bit.
+func (b bitVector) get(i int) bool {
+	return b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// count returns the number of set bits.
+func (b bitVector) count() int {
+	n := 0
+	for _, w := range b.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// all reports whether every bit is set.
+func (b bitVector) all() bool {
+	return b.count() == b.n
+}
+
+// any reports whether some bit is set.
+func (b bitVector) any() bool {
+	for _, w := range b.words {
+		if w != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mask clears the bits of the last word beyond n.
+func (b bitVector) mask() bitVector {
+	if r := b.n % 64; r != 0 {
+		b.words[len(b.words)-1] &= 1<<r - 1
+	}
+	return b
+}
+
+// not returns the complement of b.
+func (b bitVector) not() bitVector {
+	z := bitVector{words: make([]uint64, len(b.words)), n: b.n}
+	for i, w := range b.words {
+		z.words[i] = ^w
+	}
+	return z.mask()
+}
+
+// splat returns the bitVector of length n with every bit equal to the
+// single bit of b.
+func (b bitVector) splat(n int) bitVector {
+	z := bitVector{words: make([]uint64, (n+63)/64), n: n}
+	if b.get(0) {
+		for i := range z.words {
+			z.words[i] = ^uint64(0)
+		}
+	}
+	return z.mask()
+}
+
+// bitLogic returns the bitVector x fn y, computed a word at a time.
+// Either operand may have length 1, a scalar in disguise.
+func bitLogic(x bitVector, fn func(x, y uint64) uint64, y bitVector) (bitVector, bool) {
+	n, ok := packedLen(x.n, y.n)
+	if !ok {
+		return bitVector{}, false
+	}
+	if x.n != n {
+		x = x.splat(n)
+	}
+	if y.n != n {
+		y = y.splat(n)
+	}
+	z := bitVector{words: make([]uint64, len(x.words)), n: n}
+	for i := range z.words {
+		z.words[i] = fn(x.words[i], y.words[i])
+	}
+	return z.mask(), true
+}
+
+// each calls f with the index of each set bit, in increasing order.
+func (b bitVector) each(f func(i int)) {
+	for i, w := range b.words {
+		for w != 0 {
+			f(i*64 + bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+}
+
+// bitSel implements u sel v for a vector v and a packed u of truth
+// values of the same length, visiting only the set bits of u. It
+// reports false if the operands are not of that form.
+func bitSel(u, v Value) (Value, bool) {
+	p, ok := u.(PackedVector)
+	if !ok || p.kind != packedBool {
+		return nil, false
+	}
+	b := p.bits
+	switch v := v.(type) {
+	case Vector:
+		if b.n != len(v) {
+			return nil, false
+		}
+		result := make(Vector, 0, b.count())
+		b.each(func(i int) {
+			result = append(result, v[i])
+		})
+		return result, true
+	case PackedVector:
+		if b.n != v.Len() {
+			return nil, false
+		}
+		switch v.kind {
+		case packedInt:
+			x := make([]int64, 0, b.count())
+			b.each(func(i int) {
+				x = append(x, v.ints[i])
+			})
+			return packedOrEmpty(newPackedInts(x)), true
+		case packedFloat:
+			x := make([]float64, 0, b.count())
+			b.each(func(i int) {
+				x = append(x, v.floats[i])
+			})
+			return packedOrEmpty(newPackedFloats(x)), true
+		case packedChar:
+			x := make([]rune, 0, b.count())
+			b.each(func(i int) {
+				x = append(x, v.chars[i])
+			})
+			return packedOrEmpty(newPackedChars(x)), true
+		case packedBool:
+			z := bitVector{n: b.count()}
+			z.words = make([]uint64, (z.n+63)/64)
+			j := 0
+			b.each(func(i int) {
+				if v.bits.get(i) {
+					z.words[j/64] |= 1 << (j % 64)
+				}
+				j++
+			})
+			return packedOrEmpty(newPackedBools(z)), true
+		}
+	}
+	return nil, false
+}
+
+// packedOrEmpty returns p, or an empty Vector if p is empty, as
+// packed vectors are never empty.
+func packedOrEmpty(p PackedVector) Value {
+	if p.Len() == 0 {
+		return Vector{}
+	}
+	return p
+}
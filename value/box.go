@@ -0,0 +1,374 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"robpike.io/ivy/config"
+)
+
+// Box is an enclosed value. It is a scalar holding an arbitrary
+// value, so vectors and matrices of boxes can hold values of
+// differing shapes, such as a list of names or per-group results.
+// Elementwise operators apply to the contents of a box and box
+// the result, as in APL2.
+type Box struct {
+	value Value
+}
+
+// enclose returns v in a box. As in APL, enclosing a simple
+// scalar yields the scalar itself.
+func enclose(v Value) Value {
+	switch v.(type) {
+	case Vector, PackedVector, *Matrix, ArrowVector, Box:
+		return Box{v}
+	}
+	return v
+}
+
+// Contents returns the value held in the box.
+func (b Box) Contents() Value {
+	return b.value
+}
+
+func (b Box) String() string {
+	return "(" + b.Sprint(debugConf) + ")"
+}
+
+// Sprint prints the contents with a box drawn around them.
+func (b Box) Sprint(conf *config.Config) string {
+	lines := strings.Split(b.value.Sprint(conf), "\n")
+	width := 0
+	for _, line := range lines {
+		if w := utf8.RuneCountInString(line); w > width {
+			width = w
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("┌" + strings.Repeat("─", width) + "┐")
+	for _, line := range lines {
+		buf.WriteString("\n│" + line + strings.Repeat(" ", width-utf8.RuneCountInString(line)) + "│")
+	}
+	buf.WriteString("\n└" + strings.Repeat("─", width) + "┘")
+	return buf.String()
+}
+
+// ProgString returns ivy text that recreates the box, such as (box 1 2 3).
+func (b Box) ProgString() string {
+	return "(box " + progText(b.value) + ")"
+}
+
+// progText returns ivy text that recreates v, which unlike the
+// values found in program listings may be a float or an array.
+func progText(v Value) string {
+	switch v := v.(type) {
+	case BigFloat:
+		if v.Sign() == 0 || v.IsInf() {
+			return fmt.Sprintf("%g", v.Float)
+		}
+		digits := int(float64(v.Prec()) * 0.301029995664) // 10 log 2.
+		return fmt.Sprintf("%.*g", digits+1, v.Float)
+	case Float64:
+		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+	case Complex:
+		return progText(v.real) + "j" + progText(v.imag)
+	case Vector:
+		if len(v) > 0 && v.AllChars() {
+			return strconv.Quote(v.Sprint(debugConf))
+		}
+		elems := make([]string, len(v))
+		for i, x := range v {
+			elems[i] = progText(x)
+		}
+		switch len(v) {
+		case 0:
+			return "iota 0"
+		case 1:
+			return ", " + elems[0]
+		}
+		return strings.Join(elems, " ")
+	case PackedVector:
+		return progText(v.ToVector())
+	case ArrowVector:
+		return progText(v.ToVector())
+	case *Matrix:
+		return progText(NewIntVector(v.shape)) + " rho " + progText(NewVector(v.data))
+	}
+	return v.ProgString()
+}
+
+func (b Box) Rank() int {
+	return 0
+}
+
+func (b Box) Eval(Context) Value {
+	return b
+}
+
+func (b Box) Inner() Value {
+	return b
+}
+
+func (b Box) shrink() Value {
+	return b
+}
+
+func (b Box) toType(op string, conf *config.Config, which valueType) Value {
+	switch which {
+	case boxType:
+		return b
+	case vectorType, packedVectorType, arrowVectorType:
+		return NewVector([]Value{b})
+	case matrixType:
+		return NewMatrix([]int{1}, []Value{b})
+	}
+	Errorf("%s: cannot convert box to %s", op, which)
+	return nil
+}
+
+// contents returns the contents of v if it is a box, and otherwise v.
+func contents(v Value) Value {
+	if b, ok := v.(Box); ok {
+		return b.value
+	}
+	return v
+}
+
+// unaryBoxOp applies op to the contents of the box v.
+func unaryBoxOp(c Context, op string, v Value) Value {
+	return enclose(c.EvalUnary(op, contents(v)))
+}
+
+// binaryBoxOp applies op to the contents of u and v, at least
+// one of which is a box; the other is a scalar.
+func binaryBoxOp(c Context, u Value, op string, v Value) Value {
+	return enclose(c.EvalBinary(contents(u), op, contents(v)))
+}
+
+// disclose implements unbox. The contents of a box are returned
+// unchanged. The boxes in a vector or matrix are mixed into an
+// array with the items' axes added after those of the argument,
+// padding smaller items with zeros, or blanks if they are chars.
+func disclose(c Context, v Value) Value {
+	var outer []int
+	var elems []Value
+	switch v := v.(type) {
+	case Box:
+		return v.value
+	case Vector:
+		outer, elems = []int{len(v)}, v
+	case PackedVector:
+		outer, elems = []int{v.Len()}, v.ToVector()
+	case *Matrix:
+		outer, elems = v.shape, v.data
+	default:
+		return v
+	}
+	if !hasBoxes(elems) {
+		return v
+	}
+	// The rank of the items is the largest rank among them; items
+	// of smaller rank are treated as having leading axes of length 1.
+	rank := 0
+	for _, e := range elems {
+		if r := contents(e).Rank(); r > rank {
+			rank = r
+		}
+	}
+	if rank == 0 {
+		data := make([]Value, len(elems))
+		for i, e := range elems {
+			data[i] = contents(e)
+		}
+		if len(outer) == 1 {
+			return NewVector(data)
+		}
+		return NewMatrix(outer, data)
+	}
+	shapes := make([][]int, len(elems))
+	itemShape := make([]int, rank)
+	for i, e := range elems {
+		shapes[i] = paddedShape(contents(e), rank)
+		for j, n := range shapes[i] {
+			if n > itemShape[j] {
+				itemShape[j] = n
+			}
+		}
+	}
+	itemSize := size(itemShape)
+	data := make([]Value, len(elems)*itemSize)
+	pfor(true, itemSize, len(elems), func(lo, hi int) {
+		index := make([]int, rank)
+		for i := lo; i < hi; i++ {
+			item := itemData(contents(elems[i]))
+			fill := Value(zero)
+			if len(item) > 0 {
+				if _, ok := item[0].(Char); ok {
+					fill = Char(' ')
+				}
+			}
+			shape := shapes[i]
+			for k := range index {
+				index[k] = 0
+			}
+			for j := 0; j < itemSize; j++ {
+				// Compute the position of index within the item, if it is inside.
+				pos, inside := 0, true
+				for k, n := range shape {
+					if index[k] >= n {
+						inside = false
+						break
+					}
+					pos = pos*n + index[k]
+				}
+				if inside {
+					data[i*itemSize+j] = item[pos]
+				} else {
+					data[i*itemSize+j] = fill
+				}
+				for k := rank - 1; k >= 0; k-- {
+					index[k]++
+					if index[k] < itemShape[k] {
+						break
+					}
+					index[k] = 0
+				}
+			}
+		}
+	})
+	return NewMatrix(append(append([]int{}, outer...), itemShape...), data)
+}
+
+// paddedShape returns the shape of v extended with leading ones to the given rank.
+func paddedShape(v Value, rank int) []int {
+	var shape []int
+	switch v := v.(type) {
+	case Vector:
+		shape = []int{len(v)}
+	case PackedVector:
+		shape = []int{v.Len()}
+	case ArrowVector:
+		shape = []int{v.Len()}
+	case *Matrix:
+		shape = v.shape
+	}
+	padded := make([]int, rank)
+	for i := range padded {
+		padded[i] = 1
+	}
+	copy(padded[rank-len(shape):], shape)
+	return padded
+}
+
+// itemData returns the elements of v in row-major order.
+func itemData(v Value) []Value {
+	switch v := v.(type) {
+	case Vector:
+		return v
+	case PackedVector:
+		return v.ToVector()
+	case ArrowVector:
+		return v.ToVector()
+	case *Matrix:
+		return v.data
+	}
+	return []Value{v}
+}
+
+// depth implements unary depth: 0 for a simple scalar, 1 for an array
+// of simple scalars, and one more than the depth of its deepest item
+// for an array holding boxes. A box is one deeper than its contents.
+func depth(v Value) int {
+	switch v := v.(type) {
+	case Box:
+		return 1 + depth(v.value)
+	case Vector:
+		return 1 + itemDepth(v)
+	case PackedVector, ArrowVector:
+		return 1
+	case *Matrix:
+		return 1 + itemDepth(v.data)
+	}
+	return 0
+}
+
+// itemDepth returns the depth of the deepest item held in elems.
+func itemDepth(elems []Value) int {
+	d := 0
+	for _, e := range elems {
+		if b, ok := e.(Box); ok {
+			if n := depth(b.value); n > d {
+				d = n
+			}
+		}
+	}
+	return d
+}
+
+// hasBoxes reports whether any of the values is a box.
+func hasBoxes(values []Value) bool {
+	for _, v := range values {
+		if _, ok := v.(Box); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// boxedGrid prints the values, some of which may print on multiple lines,
+// as a grid with nrows rows and ncols columns. The cells in a row are aligned
+// at the top and each column is as wide as its widest cell. Boxes are
+// aligned left and other values right.
+func boxedGrid(conf *config.Config, values []Value, nrows, ncols int) string {
+	cells := make([][]string, len(values))
+	widths := make([]int, ncols)
+	for i, v := range values {
+		cells[i] = strings.Split(v.Sprint(conf), "\n")
+		for _, line := range cells[i] {
+			if w := utf8.RuneCountInString(line); w > widths[i%ncols] {
+				widths[i%ncols] = w
+			}
+		}
+	}
+	var b bytes.Buffer
+	var line bytes.Buffer
+	for row := 0; row < nrows; row++ {
+		height := 0
+		for col := 0; col < ncols; col++ {
+			if h := len(cells[row*ncols+col]); h > height {
+				height = h
+			}
+		}
+		for l := 0; l < height; l++ {
+			if row > 0 || l > 0 {
+				b.WriteByte('\n')
+			}
+			line.Reset()
+			for col := 0; col < ncols; col++ {
+				if col > 0 {
+					line.WriteByte(' ')
+				}
+				i := row*ncols + col
+				s := ""
+				if l < len(cells[i]) {
+					s = cells[i][l]
+				}
+				pad := strings.Repeat(" ", widths[col]-utf8.RuneCountInString(s))
+				if _, ok := values[i].(Box); ok {
+					line.WriteString(s + pad)
+				} else {
+					line.WriteString(pad + s)
+				}
+			}
+			b.WriteString(strings.TrimRight(line.String(), " "))
+		}
+	}
+	return b.String()
+}
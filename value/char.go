@@ -50,8 +50,12 @@ func (c Char) toType(op string, conf *config.Config, which valueType) Value {
 		return c
 	case vectorType:
 		return NewVector([]Value{c})
+	case packedVectorType:
+		return newPackedChars([]rune{rune(c)})
 	case matrixType:
 		return NewMatrix([]int{1}, []Value{c})
+	case boxType:
+		return c
 	}
 	Errorf("%s: cannot convert char to %s", op, which)
 	return nil
@@ -28,7 +28,7 @@ func (c Complex) Components() (Value, Value) {
 
 func simpleNumber(v Value) bool {
 	switch v.(type) {
-	case Int, BigInt, BigRat, BigFloat:
+	case Int, BigInt, BigRat, BigFloat, Float64:
 		return true
 	}
 	return false
@@ -62,10 +62,12 @@ func (c Complex) toType(op string, conf *config.Config, which valueType) Value {
 	switch which {
 	case complexType:
 		return c
-	case vectorType:
+	case vectorType, packedVectorType:
 		return NewVector([]Value{c})
 	case matrixType:
 		return NewMatrix([]int{1, 1}, []Value{c})
+	case boxType:
+		return c
 	}
 	Errorf("%s: cannot convert complex to %s", op, which)
 	return nil
@@ -121,9 +123,9 @@ func (c Complex) phase(ctx Context) Value {
 	iPos := !isNegative(c.imag)
 	if rZero {
 		if iPos {
-			return BigFloat{floatPiBy2}
+			return BigFloat{newFloat(ctx).Set(constsFor(ctx.Config()).piBy2)}
 		}
-		return BigFloat{floatMinusPiBy2}
+		return BigFloat{newFloat(ctx).Set(constsFor(ctx.Config()).minusPiBy2)}
 	}
 	atan := atan(ctx, ctx.EvalBinary(c.imag, "/", c.real))
 	// Correct the quadrants. We lose sign information in the division.
@@ -133,9 +135,9 @@ func (c Complex) phase(ctx Context) Value {
 	case rPos && iPos: // Upper right, π/4, OK.
 	case rPos && !iPos: // Lower right, -π/4, OK.
 	case !rPos && !iPos: // Lower left, π/4, subtract π.
-		atan = ctx.EvalBinary(atan, "-", BigFloat{newFloat(ctx).Set(floatPi)})
+		atan = ctx.EvalBinary(atan, "-", BigFloat{newFloat(ctx).Set(constsFor(ctx.Config()).pi)})
 	case !rPos && iPos: // Upper left, -π/4, add π.
-		atan = ctx.EvalBinary(atan, "+", BigFloat{newFloat(ctx).Set(floatPi)})
+		atan = ctx.EvalBinary(atan, "+", BigFloat{newFloat(ctx).Set(constsFor(ctx.Config()).pi)})
 	}
 	return atan
 }
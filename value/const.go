@@ -16,6 +16,7 @@ package value
 import (
 	"fmt"
 	"math/big"
+	"sync"
 
 	"robpike.io/ivy/config"
 )
@@ -50,18 +51,27 @@ var (
 	complexHalf      = newComplex(BigRat{big.NewRat(1, 2)}, zero)
 	minusOneOverTwoI Complex
 
-	// set to constPrecision
-	floatE          *big.Float
-	floatPi         *big.Float
-	floatPiBy2      *big.Float
-	floatMinusPiBy2 *big.Float
-	floatLog2       *big.Float
-	floatLog10      *big.Float
-	floatZero       *big.Float
-	floatOne        *big.Float
-	floatTwo        *big.Float
-	floatHalf       *big.Float
-	floatMinusOne   *big.Float
+	// Exact at any precision. Shared, so never modify them.
+	floatZero     = big.NewFloat(0)
+	floatOne      = big.NewFloat(1)
+	floatTwo      = big.NewFloat(2)
+	floatHalf     = big.NewFloat(0.5)
+	floatMinusOne = big.NewFloat(-1)
+)
+
+// floatConsts holds the irrational constants rounded to one precision.
+type floatConsts struct {
+	e          *big.Float
+	pi         *big.Float
+	piBy2      *big.Float
+	minusPiBy2 *big.Float
+	log2       *big.Float
+	log10      *big.Float
+}
+
+var (
+	floatConstsLock sync.Mutex
+	floatConstsMap  = make(map[uint]*floatConsts)
 )
 
 const strE = "2.7182818284590452353602874713526624977572470936999595749669676277240766303535475945713821785251664274274663919320030599218174135966290435729003342952605956307381323286279434907632338298807531952510190115738341879307021540891499348841675092447614606680822648001684774118537423454424371075390777449920695517027618386062613313845830007520449338265602976067371132007093287091274437470472306969772093101416928368190255151086574637721112523897844250569536967707854499699679468644549059879316368892300987931277361782154249992295763514822082698951936680331825288693984964651058209392398294887933203625094431173012381970684161403970198376793206832823764648042953118023287825098194558153017567173613320698112509961818815930416903515988885193458072738667385894228792284998920868058257492796104841984443634632449684875602336248270419786232090021609902353043699418491463140934317381436405462531520961836908887070167683964243781405927145635490613031072085103837505101157477041718986106873969655212671546889570350354021234078498193343210681701210056278802351930332247450158539047304199577770935036604169973297250886876966403555707162268447162560798826517871341951246652010305921236677194325278675398558944896970964097545918569563802363701621120477427228364896134225164450781824423529486363721417402388934412479635743702637552944483379980161254922785092577825620926226483262779333865664816277251640191059004916449982893150566047258027786318641551956532442586982946959308019152987211725563475463964479101459040905862984967912874068705048958586717479854667757573205681288459205413340539220001137863009455606881667400169842055804033637953764520304024322566135278369511778838638744396625322498506549958862342818997077332761717839280349465014345588970719425863987727547109629537415211151368350627526023264847287039207643100595841166120545297030236472549296669381151373227536450988890313602057248176585118063036442812314965507047510254465011727211555194866850800368532281831521960037356252794495158284188294787610852639813955990067376482922443752871846245780361929819713991475644882626039033814418232625150974827987779964373089970388867782271383605772978824125611907176639465070633045279546618550966661856647097113444740160704626215680717481877844371436988218559670959102596862002353718588748569652200050311734392073211390803293634479727355955277349071783793421637012050054513263835440001863239914907054797780566978533580489669062951194324730995876552368128590413832411607226029983305353708761389396391779574540161372236187893652605381558415871869255386061647798340254351284396129460352913325942794904337299085731580290958631382683291477116396337092400316894586360606458459251269946557248391865642097526850823075442545993769170419777800853627309417101634349076964237222943523661255725088147792231519747780605696725380171807763603462459278778465850656050780844211529697521890874019660906651803516501792504619501366585436632712549639908549144200014574760819302212066024330096412704894390397177195180699086998606636583232278709376502260"
@@ -80,40 +90,45 @@ func newFloat(c Context) *big.Float {
 	return newF(c.Config())
 }
 
+// Consts returns the values of e and pi at the precision of the context's
+// configuration, warning if the precision exceeds what the constants provide.
 func Consts(c Context) (e, pi BigFloat) {
 	conf := c.Config()
 	if conf.FloatPrec() > constPrecisionInBits {
 		fmt.Fprintf(c.Config().ErrOutput(), "warning: precision too high; only have %d digits (%d bits) of precision for e and pi", constPrecisionInDigits, constPrecisionInBits)
 	}
-	floatZero = newF(conf).SetInt64(0)
-	floatOne = newF(conf).SetInt64(1)
-	floatTwo = newF(conf).SetInt64(2)
-	floatHalf = newF(conf).SetFloat64(0.5)
-	floatMinusOne = newF(conf).SetInt64(-1)
-	var ok bool
-	floatE, ok = newF(conf).SetString(strE)
-	if !ok {
-		panic("setting e")
-	}
-	floatPi, ok = newF(conf).SetString(strPi)
-	if !ok {
-		panic("setting pi")
+	k := constsFor(conf)
+	return BigFloat{newF(conf).Set(k.e)}, BigFloat{newF(conf).Set(k.pi)}
+}
+
+// constsFor returns the irrational constants rounded to the precision of conf.
+// Each precision's set is built once and shared by every context using it,
+// so the values must not be modified.
+func constsFor(conf *config.Config) *floatConsts {
+	prec := conf.FloatPrec()
+	floatConstsLock.Lock()
+	defer floatConstsLock.Unlock()
+	if k := floatConstsMap[prec]; k != nil {
+		return k
 	}
-	floatPiBy2, ok = newF(conf).SetString(strPi)
-	if !ok {
-		panic("setting pi")
+	k := &floatConsts{
+		e:     parseConst(conf, strE, "e"),
+		pi:    parseConst(conf, strPi, "pi"),
+		log2:  parseConst(conf, strLog2, "log(2)"),
+		log10: parseConst(conf, strLog10, "log(10)"),
 	}
-	floatPiBy2.Quo(floatPiBy2, floatTwo)
-	floatMinusPiBy2 = newF(conf).Neg(floatPiBy2)
-	floatLog2, ok = newF(conf).SetString(strLog2)
-	if !ok {
-		panic("setting log(2)")
-	}
-	floatLog10, ok = newF(conf).SetString(strLog10)
+	k.piBy2 = newF(conf).Quo(k.pi, floatTwo)
+	k.minusPiBy2 = newF(conf).Neg(k.piBy2)
+	floatConstsMap[prec] = k
+	return k
+}
+
+func parseConst(conf *config.Config, str, name string) *big.Float {
+	f, ok := newF(conf).SetString(str)
 	if !ok {
-		panic("setting log(10)")
+		panic("setting " + name)
 	}
-	return BigFloat{newF(conf).Set(floatE)}, BigFloat{newF(conf).Set(floatPi)}
+	return f
 }
 
 // -1/2i is remarkably hard to build.
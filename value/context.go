@@ -32,6 +32,27 @@ type Decomposable interface {
 	Operands() (left, right Expr)
 }
 
+// Loop is implemented by the Expr for a loop statement in a function body.
+type Loop interface {
+	// Cond returns the condition of a while loop, or nil for a for loop.
+	Cond() Expr
+
+	// Iterate starts the loop. The function it returns assigns the loop
+	// variable of a for loop its next value, and reports whether there was
+	// one. For a while loop it always reports true.
+	Iterate(c Context) func() bool
+
+	// Body returns the statements of the loop.
+	Body() []Expr
+}
+
+// Jump is implemented by the Expr for a break or continue statement,
+// which leaves the innermost loop or its current iteration.
+type Jump interface {
+	// IsBreak reports whether the statement is a break.
+	IsBreak() bool
+}
+
 // UnaryOp is the interface implemented by a simple unary operator.
 type UnaryOp interface {
 	EvalUnary(c Context, right Value) Value
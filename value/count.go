@@ -0,0 +1,178 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"sort"
+)
+
+// count returns a matrix with a row for each distinct element of v,
+// in the order of first occurrence, holding the element and the number
+// of times it occurs.
+func count(v Vector) *Matrix {
+	index := make(map[setKey]int)
+	var elems []Value
+	var counts []int
+	for _, x := range v {
+		k := keyOf(x)
+		i, ok := index[k]
+		if !ok {
+			i = len(elems)
+			index[k] = i
+			elems = append(elems, x)
+			counts = append(counts, 0)
+		}
+		counts[i]++
+	}
+	return countMatrix(elems, counts)
+}
+
+func countScalar(c Context, v Value) Value {
+	return count(NewVector([]Value{v}))
+}
+
+// countArrow is count for a column, which it reads directly
+// when the column holds integers or floating-point numbers.
+func countArrow(v ArrowVector) *Matrix {
+	if x, ok := v.int64s(); ok {
+		elems, counts := countInts(x)
+		values := make([]Value, len(elems))
+		for i, e := range elems {
+			values[i] = Int(e)
+		}
+		return countMatrix(values, counts)
+	}
+	if x, ok := v.float64s(); ok {
+		bits := make([]int64, len(x))
+		for i, f := range x {
+			if f == 0 {
+				f = 0 // Count -0 as 0.
+			}
+			bits[i] = int64(math.Float64bits(f))
+		}
+		elems, counts := countInts(bits)
+		values := make([]Value, len(elems))
+		for i, e := range elems {
+			values[i] = v.newFloat(math.Float64frombits(uint64(e)))
+		}
+		return countMatrix(values, counts)
+	}
+	return count(v.ToVector())
+}
+
+// countInts returns the distinct elements of x, in order of first
+// occurrence, and the number of times each occurs.
+func countInts(x []int64) (elems []int64, counts []int) {
+	index := make(map[int64]int)
+	for _, e := range x {
+		i, ok := index[e]
+		if !ok {
+			i = len(elems)
+			index[e] = i
+			elems = append(elems, e)
+			counts = append(counts, 0)
+		}
+		counts[i]++
+	}
+	return elems, counts
+}
+
+func countMatrix(elems []Value, counts []int) *Matrix {
+	data := make([]Value, 0, 2*len(elems))
+	for i, e := range elems {
+		data = append(data, e, Int(counts[i]))
+	}
+	return NewMatrix([]int{len(elems), 2}, data)
+}
+
+// bin returns, for each element of v, the index of the interval
+// delimited by the ascending edges that holds it: the number of
+// edges less than or equal to the element, adjusted for the origin,
+// so an element below the first edge has index origin-1.
+func bin(c Context, edges Vector, v Value) Value {
+	for i := 1; i < len(edges); i++ {
+		if toBool(c.EvalBinary(edges[i], "<", edges[i-1])) {
+			Errorf("bin: edges not in ascending order")
+		}
+	}
+	origin := c.Config().Origin()
+	index := func(x Value) Value {
+		n := sort.Search(len(edges), func(j int) bool {
+			return toBool(c.EvalBinary(x, "<", edges[j]))
+		})
+		return Int(origin - 1 + n)
+	}
+	switch v := v.(type) {
+	case Vector:
+		return NewVector(binValues(c, len(edges), v, index))
+	case *Matrix:
+		return NewMatrix(v.shape, binValues(c, len(edges), v.data, index))
+	case ArrowVector:
+		return binArrow(c, edges, v)
+	}
+	return index(v)
+}
+
+func binOp(c Context, u, v Value) Value {
+	return bin(c, u.(Vector), v)
+}
+
+func binValues(c Context, n int, v []Value, index func(Value) Value) []Value {
+	values := make([]Value, len(v))
+	work := 2 * (1 + int(math.Log2(float64(n+1))))
+	pfor(true, work, len(values), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			values[i] = index(v[i])
+		}
+	})
+	return values
+}
+
+// binArrow is bin for a column. When the column holds numbers, it
+// compares them with the edges as int64s or float64s.
+func binArrow(c Context, edges Vector, v ArrowVector) Value {
+	origin := c.Config().Origin()
+	if x, ok := v.int64s(); ok {
+		intEdges := make([]int64, len(edges))
+		allInts := true
+		for i, e := range edges {
+			n, ok := e.(Int)
+			intEdges[i] = int64(n)
+			allInts = allInts && ok
+		}
+		if allInts {
+			return binNumbers(len(x), origin, func(i int) int {
+				return sort.Search(len(intEdges), func(j int) bool { return x[i] < intEdges[j] })
+			})
+		}
+	}
+	if x, ok := v.float64s(); ok {
+		floatEdges := make([]float64, len(edges))
+		for i, e := range edges {
+			floatEdges[i] = toFloat64(c, "bin", e)
+		}
+		return binNumbers(len(x), origin, func(i int) int {
+			return sort.Search(len(floatEdges), func(j int) bool { return x[i] < floatEdges[j] })
+		})
+	}
+	return bin(c, edges, v.ToVector())
+}
+
+func binNumbers(n, origin int, search func(i int) int) Vector {
+	values := make([]Value, n)
+	pfor(true, 16, n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			values[i] = Int(origin - 1 + search(i))
+		}
+	})
+	return NewVector(values)
+}
+
+// toFloat64 returns the real number v as a float64.
+func toFloat64(c Context, op string, v Value) float64 {
+	f, _ := v.toType(op, c.Config(), bigFloatType).(BigFloat).Float64()
+	return f
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+// Each computes f@ v, applying the unary op to each item of v.
+// The items of a vector are its elements, with boxes opened, and
+// the items of a matrix are its rows, that is, the subarrays along
+// the first axis. If every result is a scalar the results form a
+// vector; otherwise they are boxed.
+func Each(c Context, op string, v Value) Value {
+	if v.Rank() == 0 {
+		return c.EvalUnary(op, contents(v))
+	}
+	n := numItems(v)
+	results := make([]Value, n)
+	pfor(safeUnary(op), 1, n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			results[i] = enclose(c.EvalUnary(op, item(v, i)))
+		}
+	})
+	return NewVector(results)
+}
+
+// EachBinary computes u f@ v, applying the binary op to corresponding
+// items of u and v. If one operand is a scalar, it is paired with each
+// item of the other.
+func EachBinary(c Context, u Value, op string, v Value) Value {
+	if u.Rank() == 0 && v.Rank() == 0 {
+		return c.EvalBinary(contents(u), op, contents(v))
+	}
+	var n int
+	switch {
+	case u.Rank() == 0:
+		n = numItems(v)
+	case v.Rank() == 0:
+		n = numItems(u)
+	default:
+		n = numItems(u)
+		if m := numItems(v); m != n {
+			Errorf("%s@: length mismatch: %d %d", op, n, m)
+		}
+	}
+	itemOf := func(x Value, i int) Value {
+		if x.Rank() == 0 {
+			return contents(x)
+		}
+		return item(x, i)
+	}
+	results := make([]Value, n)
+	pfor(safeBinary(op), 1, n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			results[i] = enclose(c.EvalBinary(itemOf(u, i), op, itemOf(v, i)))
+		}
+	})
+	return NewVector(results)
+}
+
+// Items returns the items of v, as visited by each: the elements of
+// a vector, with boxes opened, or the rows of a matrix. A scalar is
+// its own single item.
+func Items(v Value) []Value {
+	if v.Rank() == 0 {
+		return []Value{contents(v)}
+	}
+	items := make([]Value, numItems(v))
+	for i := range items {
+		items[i] = item(v, i)
+	}
+	return items
+}
+
+// numItems returns the number of items of the vector or matrix v.
+func numItems(v Value) int {
+	switch v := v.(type) {
+	case Vector:
+		return len(v)
+	case PackedVector:
+		return v.Len()
+	case ArrowVector:
+		return v.Len()
+	case *Matrix:
+		return v.shape[0]
+	}
+	Errorf("each: unexpected type %s", whichType(v))
+	return 0
+}
+
+// item returns the ith item of the vector or matrix v.
+func item(v Value, i int) Value {
+	switch v := v.(type) {
+	case Vector:
+		return contents(v[i])
+	case PackedVector:
+		return v.Get(i)
+	case ArrowVector:
+		return v.Get(i)
+	case *Matrix:
+		n := int(v.ElemSize())
+		data := v.data[i*n : (i+1)*n]
+		if len(v.shape) == 2 {
+			return NewVector(data)
+		}
+		return NewMatrix(v.shape[1:], data)
+	}
+	Errorf("each: unexpected type %s", whichType(v))
+	return nil
+}
@@ -18,14 +18,17 @@ const (
 	bigIntType
 	bigRatType
 	bigFloatType
+	float64Type
 	complexType
+	boxType
 	vectorType
+	packedVectorType
 	arrowVectorType
 	matrixType
 	numType
 )
 
-var typeName = [...]string{"int", "char", "big int", "rational", "float", "complex", "vector", "arrowVector", "matrix"}
+var typeName = [...]string{"int", "char", "big int", "rational", "float", "float64", "complex", "box", "vector", "packed vector", "arrowVector", "matrix"}
 
 func (t valueType) String() string {
 	return typeName[t]
@@ -41,10 +44,18 @@ type unaryOp struct {
 
 func (op *unaryOp) EvalUnary(c Context, v Value) Value {
 	which := whichType(v)
+	if which == packedVectorType && op.fn[packedVectorType] == nil {
+		if op.elementwise && op.fn[vectorType] == nil {
+			return packResult(op.name, unaryVectorOp(c, op.name, v))
+		}
+		which, v = vectorType, v.(PackedVector).ToVector()
+	}
 	fn := op.fn[which]
 	if fn == nil {
 		if op.elementwise {
 			switch which {
+			case boxType:
+				return unaryBoxOp(c, op.name, v)
 			case vectorType:
 				return unaryVectorOp(c, op.name, v)
 			case matrixType:
@@ -53,6 +64,9 @@ func (op *unaryOp) EvalUnary(c Context, v Value) Value {
 		}
 		Errorf("unary %s not implemented on type %s", op.name, which)
 	}
+	if c.Config().Float64() {
+		return float64Result(fn(c, v))
+	}
 	return fn(c, v)
 }
 
@@ -77,14 +91,22 @@ func whichType(v Value) valueType {
 		return bigRatType
 	case BigFloat:
 		return bigFloatType
+	case Float64:
+		return float64Type
 	case Complex:
 		return complexType
+	case Box:
+		return boxType
 	case Vector:
 		return vectorType
+	case PackedVector:
+		return packedVectorType
 	case *Matrix:
 		return matrixType
 	case ArrowVector:
 		return arrowVectorType
+	case Func:
+		Errorf("op %s used as a value", v.Sprint(debugConf))
 	}
 	Errorf("unknown type %T in whichType", v)
 	panic("which type")
@@ -101,12 +123,33 @@ func (op *binaryOp) EvalBinary(c Context, u, v Value) Value {
 	}
 	whichU, whichV := op.whichType(whichType(u), whichType(v))
 	conf := c.Config()
+	if whichU == packedVectorType || whichV == packedVectorType {
+		// Packed vectors are unpacked unless the op is elementwise
+		// on both or has its own implementation for them, which
+		// must accept both packed and unpacked operands.
+		if op.elementwise && whichU == whichV && op.fn[vectorType] == nil {
+			u = u.toType(op.name, conf, packedVectorType)
+			v = v.toType(op.name, conf, packedVectorType)
+			return packResult(op.name, binaryVectorOp(c, u, op.name, v))
+		}
+		if fn := op.fn[packedVectorType]; fn != nil {
+			return fn(c, u.toType(op.name, conf, whichU), v.toType(op.name, conf, whichV))
+		}
+		if whichU == packedVectorType {
+			whichU = vectorType
+		}
+		if whichV == packedVectorType {
+			whichV = vectorType
+		}
+	}
 	u = u.toType(op.name, conf, whichU)
 	v = v.toType(op.name, conf, whichV)
 	fn := op.fn[whichV]
 	if fn == nil {
 		if op.elementwise {
 			switch whichV {
+			case boxType:
+				return binaryBoxOp(c, u, op.name, v)
 			case vectorType:
 				return binaryVectorOp(c, u, op.name, v)
 			case arrowVectorType:
@@ -117,6 +160,9 @@ func (op *binaryOp) EvalBinary(c Context, u, v Value) Value {
 		}
 		Errorf("binary %s not implemented on type %s", op.name, whichV)
 	}
+	if conf.Float64() {
+		return float64Result(fn(c, u, v))
+	}
 	return fn(c, u, v)
 }
 
@@ -151,6 +197,38 @@ func safeUnary(op string) bool {
 	return UnaryOps[op] != nil && op != "?"
 }
 
+// impureOps are the builtin ops whose results are not determined by
+// their operands and the configuration: they draw random numbers, run
+// ivy code, or depend on the format set for printing.
+var impureOps = map[string]bool{
+	"?":        true,
+	"binomial": true,
+	"expo":     true,
+	"ivy":      true,
+	"json":     true,
+	"normal":   true,
+	"poisson":  true,
+	"sample":   true,
+	"shuffle":  true,
+	"text":     true,
+	"uniform":  true,
+}
+
+// PureOp reports whether op is a builtin op, unary or binary, whose
+// result is determined by its operands and the settings that affect
+// evaluation, such as the origin and precision, so it may be computed
+// once for constant operands. The caller must check that the op has
+// not been redefined.
+func PureOp(op string, isBinary bool) bool {
+	if impureOps[op] {
+		return false
+	}
+	if isBinary {
+		return BinaryOps[op] != nil
+	}
+	return UnaryOps[op] != nil
+}
+
 // knownAssoc reports whether the binary op is known to be associative.
 func knownAssoc(op string) bool {
 	switch op {
@@ -302,12 +380,15 @@ func Reduce(c Context, op string, v Value) Value {
 	// We must be right associative; that is the grammar.
 	// -/1 2 3 == 1-2-3 is 1-(2-3) not (1-2)-3. Answer: 2.
 	switch v := v.(type) {
-	case Int, BigInt, BigRat, BigFloat, Complex:
+	case Int, BigInt, BigRat, BigFloat, Float64, Complex:
 		return v
 	case Vector:
 		if len(v) == 0 {
 			return v
 		}
+		if acc, ok := float64Reduce(c, op, v); ok {
+			return acc
+		}
 		acc := v[len(v)-1]
 		for i := len(v) - 2; i >= 0; i-- {
 			acc = c.EvalBinary(v[i], op, acc)
@@ -317,11 +398,19 @@ func Reduce(c Context, op string, v Value) Value {
 		if v.Len() == 0 {
 			return v
 		}
+		if acc, ok := float64Reduce(c, op, v); ok {
+			return acc
+		}
 		acc := v.Get(v.Len() - 1)
 		for i := v.Len() - 2; i >= 0; i-- {
 			acc = c.EvalBinary(v.Get(i), op, acc)
 		}
 		return acc
+	case PackedVector:
+		if acc, ok := packedReduce(c, op, v); ok {
+			return acc
+		}
+		return Reduce(c, op, v.ToVector())
 	case *Matrix:
 		if v.Rank() < 2 {
 			Errorf("shape for matrix is degenerate: %s", NewIntVector(v.shape))
@@ -359,7 +448,7 @@ func Reduce(c Context, op string, v Value) Value {
 // We must be right associative; that is the grammar.
 func Scan(c Context, op string, v Value) Value {
 	switch v := v.(type) {
-	case Int, BigInt, BigRat, BigFloat, Complex:
+	case Int, BigInt, BigRat, BigFloat, Float64, Complex:
 		return v
 	case Vector:
 		if len(v) == 0 {
@@ -379,6 +468,8 @@ func Scan(c Context, op string, v Value) Value {
 			}
 		}
 		return NewVector(values)
+	case PackedVector:
+		return Scan(c, op, v.ToVector())
 	case *Matrix:
 		if v.Rank() < 2 {
 			Errorf("shape for matrix is degenerate: %s", NewIntVector(v.shape))
@@ -416,16 +507,88 @@ func Scan(c Context, op string, v Value) Value {
 	panic("not reached")
 }
 
+// ReduceFirst computes a reduction such as +/% along the first axis.
+// The /% has been removed. For vectors it is the same as Reduce.
+func ReduceFirst(c Context, op string, v Value) Value {
+	m, ok := v.(*Matrix)
+	if !ok {
+		return Reduce(c, op, v)
+	}
+	if m.Rank() < 2 || m.shape[0] == 0 {
+		Errorf("shape for matrix is degenerate: %s", NewIntVector(m.shape))
+	}
+	n := m.shape[0]
+	shape := m.shape[1:]
+	stride := size(shape)
+	data := make(Vector, stride)
+	pfor(safeBinary(op), n, len(data), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			pos := i + (n-1)*stride
+			acc := m.data[pos]
+			for j := 1; j < n; j++ {
+				pos -= stride
+				acc = c.EvalBinary(m.data[pos], op, acc)
+			}
+			data[i] = acc
+		}
+	})
+	if len(shape) == 1 {
+		return NewVector(data)
+	}
+	return NewMatrix(shape, data)
+}
+
+// ScanFirst computes a scan such as +\% along the first axis.
+// The \% has been removed. For vectors it is the same as Scan.
+func ScanFirst(c Context, op string, v Value) Value {
+	var m *Matrix
+	switch v := v.(type) {
+	case ArrowVector:
+		return Scan(c, op, v.ToVector())
+	case *Matrix:
+		m = v
+	default:
+		return Scan(c, op, v)
+	}
+	if m.Rank() < 2 || m.shape[0] == 0 {
+		Errorf("shape for matrix is degenerate: %s", NewIntVector(m.shape))
+	}
+	n := m.shape[0]
+	stride := len(m.data) / n
+	data := make(Vector, len(m.data))
+	pfor(safeBinary(op), n, stride, func(lo, hi int) {
+		column := make(Vector, n)
+		for i := lo; i < hi; i++ {
+			// This is fundamentally O(n²) in the general case.
+			// We make it O(n) for known associative ops.
+			data[i] = m.data[i]
+			column[0] = m.data[i]
+			for j := 1; j < n; j++ {
+				pos := i + j*stride
+				column[j] = m.data[pos]
+				if knownAssoc(op) {
+					data[pos] = c.EvalBinary(data[pos-stride], op, m.data[pos])
+				} else {
+					data[pos] = Reduce(c, op, column[:j+1])
+				}
+			}
+		}
+	})
+	return NewMatrix(m.shape, data)
+}
+
 // unaryVectorOp applies op elementwise to i.
 func unaryVectorOp(c Context, op string, i Value) Value {
-	u := i.(Vector)
-	n := make([]Value, len(u))
-	pfor(safeUnary(op), 1, len(n), func(lo, hi int) {
-		for k := lo; k < hi; k++ {
-			n[k] = c.EvalUnary(op, u[k])
-		}
+	if w, ok := packedUnaryOp(c, op, i); ok {
+		return w
+	}
+	u := unpacked(i)
+	if w, ok := float64UnaryVectorOp(c, op, u); ok {
+		return w
+	}
+	return elementwise(safeUnary(op), 1, op, len(u), func(k int) Value {
+		return c.EvalUnary(op, u[k])
 	})
-	return NewVector(n)
 }
 
 // unaryMatrixOp applies op elementwise to i.
@@ -442,69 +605,54 @@ func unaryMatrixOp(c Context, op string, i Value) Value {
 
 // binaryVectorOp applies op elementwise to i and j.
 func binaryVectorOp(c Context, i Value, op string, j Value) Value {
-	u, v := i.(Vector), j.(Vector)
+	if w, ok := packedBinaryOp(c, i, op, j); ok {
+		return w
+	}
+	u, v := unpacked(i), unpacked(j)
+	if w, ok := float64VectorOp(c, u, op, v); ok {
+		return w
+	}
 	if len(u) == 1 {
-		n := make([]Value, len(v))
-		pfor(safeBinary(op), 1, len(n), func(lo, hi int) {
-			for k := lo; k < hi; k++ {
-				n[k] = c.EvalBinary(u[0], op, v[k])
-			}
+		return elementwise(safeBinary(op), 1, op, len(v), func(k int) Value {
+			return c.EvalBinary(u[0], op, v[k])
 		})
-		return NewVector(n)
 	}
 	if len(v) == 1 {
-		n := make([]Value, len(u))
-		pfor(safeBinary(op), 1, len(n), func(lo, hi int) {
-			for k := lo; k < hi; k++ {
-				n[k] = c.EvalBinary(u[k], op, v[0])
-			}
+		return elementwise(safeBinary(op), 1, op, len(u), func(k int) Value {
+			return c.EvalBinary(u[k], op, v[0])
 		})
-		return NewVector(n)
 	}
 	u.sameLength(v)
-	n := make([]Value, len(u))
-	pfor(safeBinary(op), 1, len(n), func(lo, hi int) {
-		for k := lo; k < hi; k++ {
-			n[k] = c.EvalBinary(u[k], op, v[k])
-		}
+	return elementwise(safeBinary(op), 1, op, len(u), func(k int) Value {
+		return c.EvalBinary(u[k], op, v[k])
 	})
-	return NewVector(n)
 }
 
 func binaryArrowVectorOp(c Context, u ValueGetter, op string, v ValueGetter) Value {
+	if w, ok := float64VectorOp(c, u, op, v); ok {
+		return w
+	}
 	/*
 		u := i.(Vector)
 		v := j.(ArrowVector)
 	*/
 
 	if u.Len() == 1 {
-		n := make([]Value, v.Len())
-		pfor(safeBinary(op), 1, len(n), func(lo, hi int) {
-			for k := lo; k < hi; k++ {
-				n[k] = c.EvalBinary(u.Get(0), op, v.Get(k))
-			}
+		return elementwise(safeBinary(op), 1, op, v.Len(), func(k int) Value {
+			return c.EvalBinary(u.Get(0), op, v.Get(k))
 		})
-		return NewVector(n)
 	}
 	if v.Len() == 1 {
-		n := make([]Value, u.Len())
-		pfor(safeBinary(op), 1, len(n), func(lo, hi int) {
-			for k := lo; k < hi; k++ {
-				n[k] = c.EvalBinary(u.Get(k), op, v.Get(0))
-			}
+		return elementwise(safeBinary(op), 1, op, u.Len(), func(k int) Value {
+			return c.EvalBinary(u.Get(k), op, v.Get(0))
 		})
-		return NewVector(n)
 	}
 	if u.Len() != v.Len() {
 		panic("NO MATCH")
 	}
-	n := make([]Value, u.Len())
-	pfor(safeBinary(op), 1, len(n), func(lo, hi int) {
-		for k := lo; k < hi; k++ {
-			n[k] = c.EvalBinary(u.Get(k), op, v.Get(k))
-		}
+	return elementwise(safeBinary(op), 1, op, u.Len(), func(k int) Value {
+		return c.EvalBinary(u.Get(k), op, v.Get(k))
 	})
-	return NewVector(n)
 }
 
 // binaryMatrixOp applies op elementwise to i and j.
@@ -599,6 +747,8 @@ func isZero(v Value) bool {
 		return v.Sign() == 0
 	case BigFloat:
 		return v.Sign() == 0
+	case Float64:
+		return v == 0
 	case Complex:
 		return isZero(v.real) && isZero(v.imag)
 	}
@@ -616,6 +766,8 @@ func isNegative(v Value) bool {
 		return v.Sign() < 0
 	case BigFloat:
 		return v.Sign() < 0
+	case Float64:
+		return v < 0
 	case Complex:
 		return false
 	}
@@ -644,6 +796,14 @@ func compare(v Value, i int) int {
 	case BigFloat:
 		r := big.NewFloat(float64(i))
 		return -r.Sub(r, v.Float).Sign()
+	case Float64:
+		switch {
+		case float64(v) < float64(i):
+			return -1
+		case float64(v) == float64(i):
+			return 0
+		}
+		return 1
 	case Complex:
 		return -1
 	}
@@ -664,6 +824,8 @@ func isTrue(fnName string, v Value) bool {
 		return true // If it's a BigRat, it can't be 0 - that's an Int.
 	case BigFloat:
 		return i.Float.Sign() != 0
+	case Float64:
+		return i != 0
 	case Complex:
 		return !isZero(v)
 	default:
@@ -672,6 +834,13 @@ func isTrue(fnName string, v Value) bool {
 	}
 }
 
+// IsTrue reports whether v, the condition of a conditional or loop
+// in the op named fnName, represents boolean truth. If v is not a
+// scalar, an error results.
+func IsTrue(fnName string, v Value) bool {
+	return isTrue(fnName, v)
+}
+
 // emod is a restricted form of Euclidean integer modulus.
 // Used by encode, and only works for integers.
 func emod(op string, c Context, a, b Value) Value {
@@ -697,16 +866,79 @@ func ediv(op string, c Context, a, b Value) Value {
 // EvalFunctionBody evaluates the list of expressions inside a function,
 // possibly with conditionals that generate an early return.
 func EvalFunctionBody(context Context, fnName string, body []Expr) Value {
+	v, _ := evalBlock(context, fnName, body)
+	return v
+}
+
+// How control leaves a block of statements.
+const (
+	fellThrough = iota
+	returned
+	broke
+	continued
+)
+
+// evalBlock evaluates the statements of a function body or loop body.
+// It returns the value of the last statement evaluated, or the value
+// returned by a conditional, and how control left the block.
+func evalBlock(context Context, fnName string, body []Expr) (Value, int) {
 	var v Value
 	for _, e := range body {
+		switch e := e.(type) {
+		case Jump:
+			return v, jump(e)
+		case Loop:
+			w, how := evalLoop(context, fnName, e)
+			if how == returned {
+				return w, how
+			}
+			if w != nil {
+				v = w
+			}
+			continue
+		}
 		if d, ok := e.(Decomposable); ok && d.Operator() == ":" {
 			left, right := d.Operands()
 			if isTrue(fnName, left.Eval(context)) {
-				return right.Eval(context)
+				if j, ok := right.(Jump); ok {
+					return v, jump(j)
+				}
+				return right.Eval(context), returned
 			}
 			continue
 		}
 		v = e.Eval(context)
 	}
-	return v
+	return v, fellThrough
+}
+
+// evalLoop runs the loop until its condition fails, it runs out of
+// values, or its body executes a break or a conditional return.
+func evalLoop(context Context, fnName string, loop Loop) (Value, int) {
+	var v Value
+	next := loop.Iterate(context)
+	cond := loop.Cond()
+	for next() {
+		if cond != nil && !isTrue(fnName, cond.Eval(context)) {
+			break
+		}
+		w, how := evalBlock(context, fnName, loop.Body())
+		if how == returned {
+			return w, how
+		}
+		if w != nil {
+			v = w
+		}
+		if how == broke {
+			break
+		}
+	}
+	return v, fellThrough
+}
+
+func jump(j Jump) int {
+	if j.IsBreak() {
+		return broke
+	}
+	return continued
 }
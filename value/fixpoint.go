@@ -0,0 +1,161 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"math/big"
+
+	"robpike.io/ivy/config"
+)
+
+// maxPower is the number of times power applies its op operand
+// looking for a fixpoint, or for its condition to hold, before it
+// gives up.
+const maxPower = 1 << 20
+
+// Power computes n f power v, applying the unary op fn to v n times.
+// If n is itself an op, it is the condition: fn is applied until
+// next n prev, where next is the new result and prev the one before,
+// is true.
+func Power(c Context, fn UnaryOp, n, v Value) Value {
+	if cond, ok := n.(Func); ok {
+		for i := 0; i < maxPower; i++ {
+			next := fn.EvalUnary(c, v)
+			t := cond.EvalBinary(c, next, v)
+			if t.Rank() != 0 {
+				Errorf("power: condition must be a scalar")
+			}
+			if toBool(t) {
+				return next
+			}
+			v = next
+		}
+		Errorf("power: condition not met after %d iterations", maxPower)
+	}
+	count, ok := n.(Int)
+	if !ok || count < 0 {
+		Errorf("power: count must be a non-negative small integer or an op")
+	}
+	for i := Int(0); i < count; i++ {
+		v = fn.EvalUnary(c, v)
+	}
+	return v
+}
+
+// Fixpoint computes f power v, applying the unary op fn repeatedly,
+// starting with v, until the result is identical to its argument.
+func Fixpoint(c Context, fn UnaryOp, v Value) Value {
+	for i := 0; i < maxPower; i++ {
+		next := fn.EvalUnary(c, v)
+		if identical(c, next, v) {
+			return next
+		}
+		v = next
+	}
+	Errorf("power: no fixpoint after %d iterations", maxPower)
+	return nil
+}
+
+// identical reports whether u and v have the same shape and elements.
+func identical(c Context, u, v Value) bool {
+	switch u := u.(type) {
+	case Box:
+		w, ok := v.(Box)
+		return ok && identical(c, u.Contents(), w.Contents())
+	case Func:
+		return u == v
+	case Char:
+		_, ok := v.(Char)
+		return ok && u == v
+	case *Matrix:
+		w, ok := v.(*Matrix)
+		if !ok || !sameShape(u.shape, w.shape) {
+			return false
+		}
+		for i := range u.data {
+			if !identical(c, u.data[i], w.data[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if u.Rank() != v.Rank() {
+		return false
+	}
+	if u.Rank() == 0 {
+		switch v.(type) {
+		case Box, Func, Char:
+			return false
+		}
+		if isRealFloat(u) || isRealFloat(v) {
+			return closeFloats(c.Config(), u, v)
+		}
+		return toBool(c.EvalBinary(u, "==", v))
+	}
+	n := numItems(u)
+	if numItems(v) != n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		// Items of a vector are unboxed, so compare the elements themselves.
+		if !identical(c, element(u, i), element(v, i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// closeFloats reports whether the real scalars u and v, at least one of
+// which is a float, agree to within a few bits of the float precision.
+// Iterations in floating point can otherwise alternate forever between
+// values that differ in the last bit.
+func closeFloats(conf *config.Config, u, v Value) bool {
+	if _, ok := u.(Complex); ok {
+		return false
+	}
+	if _, ok := v.(Complex); ok {
+		return false
+	}
+	_, uFloat64 := u.(Float64)
+	_, vFloat64 := v.(Float64)
+	if uFloat64 || vFloat64 {
+		x := float64(u.toType("power", conf, float64Type).(Float64))
+		y := float64(v.toType("power", conf, float64Type).(Float64))
+		if x == y || math.IsNaN(x) && math.IsNaN(y) {
+			return true
+		}
+		// Allow a difference in the last 4 bits of the 53.
+		return math.Abs(x-y) <= math.Ldexp(math.Max(math.Abs(x), math.Abs(y)), 4-53)
+	}
+	x := u.toType("power", conf, bigFloatType).(BigFloat).Float
+	y := v.toType("power", conf, bigFloatType).(BigFloat).Float
+	diff := new(big.Float).SetPrec(conf.FloatPrec()).Sub(x, y)
+	diff.Abs(diff)
+	size := new(big.Float).Abs(x)
+	if ay := new(big.Float).Abs(y); ay.Cmp(size) > 0 {
+		size = ay
+	}
+	// Allow a difference in the last 4 bits.
+	size.SetMantExp(size, 4-int(conf.FloatPrec()))
+	return diff.Cmp(size) <= 0
+}
+
+// isRealFloat reports whether v is a BigFloat or a Float64.
+func isRealFloat(v Value) bool {
+	switch v.(type) {
+	case BigFloat, Float64:
+		return true
+	}
+	return false
+}
+
+// element returns the ith element of the vector v.
+func element(v Value, i int) Value {
+	if v, ok := v.(Vector); ok {
+		return v[i]
+	}
+	return item(v, i)
+}
@@ -0,0 +1,381 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"strconv"
+
+	"robpike.io/ivy/config"
+)
+
+// Float64 is a floating-point value held as a native float64, with
+// IEEE semantics. Floating-point results have this type rather than
+// BigFloat when the configuration's Float64 mode is set. Exact values
+// are unaffected by the mode.
+type Float64 float64
+
+func (f Float64) Rank() int {
+	return 0
+}
+
+func (f Float64) String() string {
+	return "(" + f.Sprint(debugConf) + ")"
+}
+
+func (f Float64) Sprint(conf *config.Config) string {
+	verb, prec := byte('g'), 12
+	if conf.Format() != "" {
+		v, p, ok := conf.FloatFormat()
+		if ok {
+			verb, prec = v, p
+		}
+	}
+	return strconv.FormatFloat(float64(f), verb, prec, 64)
+}
+
+func (f Float64) ProgString() string {
+	// There is no such thing as a float literal in program listings.
+	panic("float64.ProgString - cannot happen")
+}
+
+func (f Float64) Eval(Context) Value {
+	return f
+}
+
+func (f Float64) Inner() Value {
+	return f
+}
+
+func (f Float64) toType(op string, conf *config.Config, which valueType) Value {
+	switch which {
+	case float64Type:
+		return f
+	case bigFloatType:
+		if math.IsNaN(float64(f)) {
+			Errorf("%s: not implemented on NaN", op)
+		}
+		return BigFloat{newF(conf).SetFloat64(float64(f))}
+	case complexType:
+		return newComplex(f, Int(0))
+	case vectorType:
+		return NewVector([]Value{f})
+	case packedVectorType:
+		return newPackedFloats([]float64{float64(f)})
+	case arrowVectorType:
+		return NewVector([]Value{f})
+	case matrixType:
+		return NewMatrix([]int{1}, []Value{f})
+	case boxType:
+		return f
+	}
+	Errorf("%s: cannot convert float64 to %s", op, which)
+	return nil
+}
+
+// shrink returns f. Unlike a BigFloat, a Float64 stays a float even
+// if it is integral, so that, for instance, division of a float by
+// zero gives an infinity.
+func (f Float64) shrink() Value {
+	return f
+}
+
+// float64Result converts the floating-point values in the result of an
+// operation to Float64. It returns v if there are none.
+func float64Result(v Value) Value {
+	switch v := v.(type) {
+	case BigFloat:
+		f, _ := v.Float64()
+		return Float64(f)
+	case Complex:
+		re, im := float64Result(v.real), float64Result(v.imag)
+		if re != v.real || im != v.imag {
+			return newComplex(re, im)
+		}
+	case Vector:
+		if w, ok := float64Vector(v); ok {
+			return w
+		}
+	case *Matrix:
+		if data, ok := float64Vector(v.data); ok {
+			return NewMatrix(v.shape, data)
+		}
+	}
+	return v
+}
+
+// float64Vector returns a copy of v with its floating-point elements
+// converted to Float64, and whether there were any to convert.
+func float64Vector(v Vector) (Vector, bool) {
+	for i, x := range v {
+		switch x.(type) {
+		case BigFloat, Complex:
+			y := float64Result(x)
+			if y == x {
+				continue
+			}
+			w := make(Vector, len(v))
+			copy(w, v[:i])
+			w[i] = y
+			for j := i + 1; j < len(v); j++ {
+				w[j] = float64Result(v[j])
+			}
+			return w, true
+		}
+	}
+	return v, false
+}
+
+// float64Bool returns the ivy truth value of b.
+func float64Bool(b bool) Value {
+	if b {
+		return one
+	}
+	return zero
+}
+
+// float64Int returns the integer x, such as the floor of a float,
+// reporting false if it is too large or not finite.
+func float64Int(x float64) (Value, bool) {
+	if math.Abs(x) < 1<<62 {
+		return fromGoInt(int64(x)), true
+	}
+	return nil, false
+}
+
+// float64UnaryFns are the native implementations of unary ops for
+// Float64. Each reports false if the result is not a real float64,
+// such as the square root of a negative number, in which case the op
+// is evaluated by its BigFloat implementation.
+var float64UnaryFns = map[string]func(x float64) (Value, bool){
+	"+":     func(x float64) (Value, bool) { return Float64(x), true },
+	"-":     func(x float64) (Value, bool) { return Float64(-x), true },
+	"/":     func(x float64) (Value, bool) { return Float64(1 / x), true },
+	"abs":   func(x float64) (Value, bool) { return Float64(math.Abs(x)), true },
+	"float": func(x float64) (Value, bool) { return Float64(x), true },
+	"sgn": func(x float64) (Value, bool) {
+		switch {
+		case x > 0:
+			return one, true
+		case x < 0:
+			return minusOne, true
+		case x == 0:
+			return zero, true
+		}
+		return Float64(x), true // NaN.
+	},
+	"floor": func(x float64) (Value, bool) { return float64Int(math.Floor(x)) },
+	"ceil":  func(x float64) (Value, bool) { return float64Int(math.Ceil(x)) },
+	"sqrt":  func(x float64) (Value, bool) { return Float64(math.Sqrt(x)), !(x < 0) },
+	"**":    func(x float64) (Value, bool) { return Float64(math.Exp(x)), true },
+	"log":   func(x float64) (Value, bool) { return Float64(math.Log(x)), !(x < 0) },
+	"sin":   func(x float64) (Value, bool) { return Float64(math.Sin(x)), true },
+	"cos":   func(x float64) (Value, bool) { return Float64(math.Cos(x)), true },
+	"tan":   func(x float64) (Value, bool) { return Float64(math.Tan(x)), true },
+	"asin":  func(x float64) (Value, bool) { return Float64(math.Asin(x)), !(math.Abs(x) > 1) },
+	"acos":  func(x float64) (Value, bool) { return Float64(math.Acos(x)), !(math.Abs(x) > 1) },
+	"atan":  func(x float64) (Value, bool) { return Float64(math.Atan(x)), true },
+	"sinh":  func(x float64) (Value, bool) { return Float64(math.Sinh(x)), true },
+	"cosh":  func(x float64) (Value, bool) { return Float64(math.Cosh(x)), true },
+	"tanh":  func(x float64) (Value, bool) { return Float64(math.Tanh(x)), true },
+	"asinh": func(x float64) (Value, bool) { return Float64(math.Asinh(x)), true },
+	"acosh": func(x float64) (Value, bool) { return Float64(math.Acosh(x)), !(x < 1) },
+	"atanh": func(x float64) (Value, bool) { return Float64(math.Atanh(x)), !(math.Abs(x) > 1) },
+}
+
+// float64BinaryFns are the native implementations of binary ops for
+// Float64, with the same convention as float64UnaryFns.
+var float64BinaryFns = map[string]func(x, y float64) (Value, bool){
+	"log": func(x, y float64) (Value, bool) {
+		return Float64(math.Log(y) / math.Log(x)), x > 0 && x != 1 && !(y < 0)
+	},
+	"+":   func(x, y float64) (Value, bool) { return Float64(x + y), true },
+	"-":   func(x, y float64) (Value, bool) { return Float64(x - y), true },
+	"*":   func(x, y float64) (Value, bool) { return Float64(x * y), true },
+	"/":   func(x, y float64) (Value, bool) { return Float64(x / y), true },
+	"min": func(x, y float64) (Value, bool) { return Float64(math.Min(x, y)), true },
+	"max": func(x, y float64) (Value, bool) { return Float64(math.Max(x, y)), true },
+	"**": func(x, y float64) (Value, bool) {
+		return Float64(math.Pow(x, y)), !(x < 0 && y != math.Trunc(y))
+	},
+	"==": func(x, y float64) (Value, bool) { return float64Bool(x == y), true },
+	"!=": func(x, y float64) (Value, bool) { return float64Bool(x != y), true },
+	"<":  func(x, y float64) (Value, bool) { return float64Bool(x < y), true },
+	"<=": func(x, y float64) (Value, bool) { return float64Bool(x <= y), true },
+	">":  func(x, y float64) (Value, bool) { return float64Bool(x > y), true },
+	">=": func(x, y float64) (Value, bool) { return float64Bool(x >= y), true },
+}
+
+// float64Unary returns the implementation of the unary op for Float64:
+// its native one if it has one, otherwise bigFn applied to the value
+// converted to BigFloat. It returns nil if there is neither.
+func float64Unary(name string, bigFn unaryFn) unaryFn {
+	native := float64UnaryFns[name]
+	if native == nil && bigFn == nil {
+		return nil
+	}
+	return func(c Context, v Value) Value {
+		if native != nil {
+			if r, ok := native(float64(v.(Float64))); ok {
+				return r
+			}
+		}
+		if bigFn == nil {
+			Errorf("unary %s not implemented on type %s", name, float64Type)
+		}
+		return float64Result(bigFn(c, v.toType(name, c.Config(), bigFloatType)))
+	}
+}
+
+// float64Binary is the analog of float64Unary for binary ops. The
+// BigFloat implementation is used if either argument is not a Float64.
+func float64Binary(name string, bigFn binaryFn) binaryFn {
+	native := float64BinaryFns[name]
+	if native == nil && bigFn == nil {
+		return nil
+	}
+	return func(c Context, u, v Value) Value {
+		x, xOK := u.(Float64)
+		y, yOK := v.(Float64)
+		if native != nil && xOK && yOK {
+			if r, ok := native(float64(x), float64(y)); ok {
+				return r
+			}
+		}
+		if bigFn == nil {
+			Errorf("binary %s not implemented on type %s", name, float64Type)
+		}
+		conf := c.Config()
+		if xOK {
+			u = u.toType(name, conf, bigFloatType)
+		}
+		if yOK {
+			v = v.toType(name, conf, bigFloatType)
+		}
+		return float64Result(bigFn(c, u, v))
+	}
+}
+
+// float64VectorOp evaluates u op v elementwise in float64 mode when
+// the operands are real and at least one holds floats, reading Arrow
+// columns directly. It reports false if it cannot.
+func float64VectorOp(c Context, u ValueGetter, op string, v ValueGetter) (Value, bool) {
+	native := float64BinaryFns[op]
+	if native == nil || !c.Config().Float64() {
+		return nil, false
+	}
+	x, xFloat := float64Elems(c, u)
+	y, yFloat := float64Elems(c, v)
+	if len(x) == 0 || len(y) == 0 || !xFloat && !yFloat {
+		// Empty operands are left to the general path.
+		return nil, false
+	}
+	n := len(x)
+	if n == 1 {
+		n = len(y)
+	} else if len(y) != 1 && len(y) != n {
+		return nil, false
+	}
+	return elementwise(true, 1, op, n, func(i int) Value {
+		xi, yi := x[0], y[0]
+		if len(x) > 1 {
+			xi = x[i]
+		}
+		if len(y) > 1 {
+			yi = y[i]
+		}
+		r, ok := native(xi, yi)
+		if !ok {
+			r = c.EvalBinary(Float64(xi), op, Float64(yi))
+		}
+		return r
+	}), true
+}
+
+// float64Elems returns the elements of g, an Arrow column or a vector
+// of real numbers, as float64s, and whether any of them is a float.
+// It returns nil if g has other elements.
+func float64Elems(c Context, g ValueGetter) ([]float64, bool) {
+	switch g := g.(type) {
+	case ArrowVector:
+		if x, ok := g.float64s(); ok {
+			return x, true
+		}
+		if x, ok := g.int64s(); ok {
+			f := make([]float64, len(x))
+			for i, e := range x {
+				f[i] = float64(e)
+			}
+			return f, false
+		}
+		return nil, false
+	case PackedVector:
+		x, ok := g.float64s()
+		return x, ok && g.kind == packedFloat
+	case Vector:
+		f := make([]float64, len(g))
+		isFloat := false
+		for i, e := range g {
+			switch e := e.(type) {
+			case Float64:
+				f[i] = float64(e)
+				isFloat = true
+			case Int:
+				f[i] = float64(e)
+			case BigInt, BigRat, BigFloat:
+				f[i] = toFloat64(c, "float64", e)
+			default:
+				return nil, false
+			}
+		}
+		return f, isFloat
+	}
+	return nil, false
+}
+
+// float64UnaryVectorOp is the analog of float64VectorOp for unary ops.
+func float64UnaryVectorOp(c Context, op string, v Vector) (Value, bool) {
+	native := float64UnaryFns[op]
+	if native == nil || !c.Config().Float64() {
+		return nil, false
+	}
+	x, isFloat := float64Elems(c, v)
+	if !isFloat {
+		return nil, false
+	}
+	return elementwise(true, 1, op, len(x), func(i int) Value {
+		r, ok := native(x[i])
+		if !ok {
+			r = c.EvalUnary(op, Float64(x[i]))
+		}
+		return r
+	}), true
+}
+
+// float64Reduce computes the reduction op/ v in float64 mode when v
+// holds floats. It reports false if it cannot.
+func float64Reduce(c Context, op string, v ValueGetter) (Value, bool) {
+	native := float64BinaryFns[op]
+	if native == nil || !c.Config().Float64() || v.Len() == 0 {
+		return nil, false
+	}
+	x, isFloat := float64Elems(c, v)
+	if !isFloat {
+		return nil, false
+	}
+	acc := Value(Float64(x[len(x)-1]))
+	for i := len(x) - 2; i >= 0; i-- {
+		y, ok := acc.(Float64)
+		if !ok {
+			return nil, false // A comparison produced an integer.
+		}
+		r, ok := native(x[i], float64(y))
+		if !ok {
+			r = c.EvalBinary(Float64(x[i]), op, acc)
+		}
+		acc = r
+	}
+	return acc, true
+}
@@ -26,13 +26,20 @@ import (
 // integer with '%d'.
 func fmtText(c Context, u, v Value) Value {
 	config := c.Config()
+	// Text leaves its arguments alone, so packed vectors arrive here.
+	if p, ok := u.(PackedVector); ok {
+		u = p.ToVector()
+	}
+	if p, ok := v.(PackedVector); ok {
+		v = p.ToVector()
+	}
 	format, verb := formatString(config, u)
 	if format == "" {
 		Errorf("illegal format %q", u.Sprint(config))
 	}
 	var b bytes.Buffer
 	switch val := v.(type) {
-	case Int, BigInt, BigRat, BigFloat, Char:
+	case Int, BigInt, BigRat, BigFloat, Float64, Char:
 		formatOne(c, &b, format, verb, val)
 	case Complex:
 		formatOne(c, &b, format, verb, val.real)
@@ -169,6 +176,8 @@ func formatOne(c Context, w io.Writer, format string, verb byte, v Value) {
 		case BigFloat:
 			i, _ := val.Int64()
 			fmt.Fprintf(w, format, i)
+		case Float64:
+			fmt.Fprintf(w, format, int64(val))
 		case Complex:
 			Errorf("%%%c not implemented for complex: %v", verb, val)
 		}
@@ -188,6 +197,8 @@ func formatOne(c Context, w io.Writer, format string, verb byte, v Value) {
 		case BigFloat:
 			i, _ := val.Int64()
 			fmt.Fprintf(w, format, string(int32(i)))
+		case Float64:
+			fmt.Fprintf(w, format, string(int32(val)))
 		case Complex:
 			Errorf("%%%c not implemented for complex: %v", verb, val)
 		}
@@ -218,6 +229,8 @@ func formatOne(c Context, w io.Writer, format string, verb byte, v Value) {
 			}
 			i, _ := val.Int(big.NewInt(0)) // TODO: Truncates towards zero. Do rounding?
 			fmt.Fprintf(w, format, i)
+		case Float64:
+			fmt.Fprintf(w, format, int64(val))
 		case Complex:
 			formatOne(c, w, format, verb, val.real)
 			fmt.Fprint(w, "j")
@@ -241,6 +254,8 @@ func formatOne(c Context, w io.Writer, format string, verb byte, v Value) {
 			fmt.Fprintf(w, format, f)
 		case BigFloat:
 			fmt.Fprintf(w, format, val.Float)
+		case Float64:
+			fmt.Fprintf(w, format, float64(val))
 		case Complex:
 			formatOne(c, w, format, verb, val.real)
 			fmt.Fprint(w, "j")
@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "robpike.io/ivy/config"
+
+// Func is an op held as a value, made by evaluating an anonymous op
+// such as {x*2}. It can be assigned to a variable, which can then be
+// used wherever an operator can, including in reductions and products.
+type Func struct {
+	op   FuncOp
+	text string
+}
+
+// FuncOp is the implementation of a Func, which may be applied
+// as either a unary or a binary operator.
+type FuncOp interface {
+	UnaryOp
+	BinaryOp
+}
+
+// NewFunc returns a Func that applies op. The text is its source,
+// used to print the Func.
+func NewFunc(op FuncOp, text string) Func {
+	return Func{op, text}
+}
+
+// Op returns the implementation of the Func.
+func (f Func) Op() FuncOp {
+	return f.op
+}
+
+func (f Func) EvalUnary(c Context, right Value) Value {
+	return f.op.EvalUnary(c, right)
+}
+
+func (f Func) EvalBinary(c Context, left, right Value) Value {
+	return f.op.EvalBinary(c, left, right)
+}
+
+func (f Func) String() string {
+	return "(" + f.text + ")"
+}
+
+func (f Func) Sprint(*config.Config) string {
+	return f.text
+}
+
+func (f Func) ProgString() string {
+	return f.text
+}
+
+func (f Func) Rank() int {
+	return 0
+}
+
+func (f Func) Eval(Context) Value {
+	return f
+}
+
+func (f Func) Inner() Value {
+	return f
+}
+
+func (f Func) shrink() Value {
+	return f
+}
+
+func (f Func) toType(op string, conf *config.Config, which valueType) Value {
+	Errorf("%s: op %s used as a value", op, f.text)
+	return nil
+}
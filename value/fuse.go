@@ -0,0 +1,202 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+// Loop fusion. An expression such as (a*b)+c-d on vectors would
+// otherwise make a full intermediate vector for each op; fused, it is
+// evaluated in one pass over the elements, each element going through
+// the whole chain of ops.
+
+// Fused is a tree of elementwise ops to be evaluated together. Its
+// leaves are operands, already evaluated.
+type Fused struct {
+	Op    string // The op; empty for a leaf.
+	Left  *Fused // The left operand of a binary op; nil for a unary op or leaf.
+	Right *Fused // The operand of a unary op or right operand of a binary op.
+	Leaf  int    // For a leaf, the index of the operand.
+}
+
+// FusibleOp reports whether the builtin op, unary or binary, may be
+// part of a fused chain: it applies elementwise to vectors, each
+// element independently, and is safe to parallelize. The caller must
+// check that the op has not been redefined.
+func FusibleOp(op string, isBinary bool) bool {
+	if isBinary {
+		b, ok := BinaryOps[op].(*binaryOp)
+		if !ok || !safeBinary(op) || !b.elementwise || b.whichType == nil {
+			return false
+		}
+		for _, t := range arrayTypes {
+			if b.fn[t] != nil {
+				return false
+			}
+		}
+		return true
+	}
+	u, ok := UnaryOps[op].(*unaryOp)
+	if !ok || !safeUnary(op) || !u.elementwise {
+		return false
+	}
+	for _, t := range arrayTypes {
+		if u.fn[t] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayTypes are the types of arrays. An op that has an implementation
+// for one of them does not simply apply to each element in turn.
+var arrayTypes = []valueType{vectorType, packedVectorType, arrowVectorType, matrixType}
+
+// EvalFused evaluates f with the given operands. If the operands are
+// scalars and arrays of the same shape, it makes a single pass over
+// their elements, in parallel. Otherwise, or if an error occurs, it
+// evaluates the ops one at a time, so the result, or the error, is the
+// same either way. Packed vectors and Arrow columns are read an element
+// at a time, like ordinary vectors. In float64 mode the operands must
+// hold only floats, since an op on a vector holding floats converts its
+// exact elements to floats, which applying it to one element at a time
+// would not.
+func EvalFused(c Context, f *Fused, operands []Value) (result Value) {
+	elems, shape := fusedElems(c, operands)
+	if elems == nil {
+		return f.eval(c, operands)
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			if _, ok := err.(Error); !ok {
+				panic(err)
+			}
+			result = f.eval(c, operands)
+		}
+	}()
+	n := size(shape)
+	work := f.size()
+	if len(shape) > 1 {
+		values := make([]Value, n)
+		pfor(true, work, n, func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				values[i] = f.at(c, operands, elems, i)
+			}
+		})
+		return NewMatrix(shape, values)
+	}
+	// Give the result the form the ops would, one at a time.
+	v := elementwise(true, work, f.Op, n, func(i int) Value {
+		return f.at(c, operands, elems, i)
+	})
+	for _, x := range operands {
+		if _, ok := x.(PackedVector); ok {
+			return packResult(f.Op, v)
+		}
+	}
+	return v
+}
+
+// fusedElems returns, for each operand, its elements if it is an array
+// or nil if it is a scalar, and the common shape of the arrays. It
+// returns nil if the operands cannot be fused: there is no array of
+// more than one element, the arrays differ in shape, or in float64
+// mode an operand holds something other than floats.
+func fusedElems(c Context, operands []Value) ([]ValueGetter, []int) {
+	elems := make([]ValueGetter, len(operands))
+	var shape []int
+	onlyFloats := c.Config().Float64()
+	for i, v := range operands {
+		var s []int
+		switch v := v.(type) {
+		case Vector, PackedVector:
+			elems[i] = v.(ValueGetter)
+			s = []int{elems[i].Len()}
+		case ArrowVector:
+			if !v.AllInts() && !v.isFloat() {
+				return nil, nil
+			}
+			elems[i] = v
+			s = []int{v.Len()}
+		case *Matrix:
+			elems[i] = v.Data()
+			s = v.Shape()
+		default:
+			if onlyFloats && !isFloat64(v) {
+				return nil, nil
+			}
+			continue
+		}
+		if onlyFloats && !allFloat64(elems[i]) {
+			return nil, nil
+		}
+		if shape != nil && !sameShape(shape, s) {
+			return nil, nil
+		}
+		shape = s
+	}
+	if shape == nil || size(shape) <= 1 {
+		return nil, nil
+	}
+	return elems, shape
+}
+
+// isFloat64 reports whether v is a Float64.
+func isFloat64(v Value) bool {
+	_, ok := v.(Float64)
+	return ok
+}
+
+// allFloat64 reports whether the elements of g are all Float64s.
+func allFloat64(g ValueGetter) bool {
+	switch g := g.(type) {
+	case PackedVector:
+		return g.kind == packedFloat
+	case ArrowVector:
+		return g.isFloat()
+	}
+	for i := 0; i < g.Len(); i++ {
+		if !isFloat64(g.Get(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// size returns the number of ops in f.
+func (f *Fused) size() int {
+	if f.Op == "" {
+		return 0
+	}
+	n := 1 + f.Right.size()
+	if f.Left != nil {
+		n += f.Left.size()
+	}
+	return n
+}
+
+// at returns the ith element of f.
+func (f *Fused) at(c Context, operands []Value, elems []ValueGetter, i int) Value {
+	if f.Op == "" {
+		if elems[f.Leaf] == nil {
+			return operands[f.Leaf]
+		}
+		return elems[f.Leaf].Get(i)
+	}
+	right := f.Right.at(c, operands, elems, i)
+	if f.Left == nil {
+		return c.EvalUnary(f.Op, right)
+	}
+	return c.EvalBinary(f.Left.at(c, operands, elems, i), f.Op, right)
+}
+
+// eval evaluates f an op at a time, as if it were not fused.
+func (f *Fused) eval(c Context, operands []Value) Value {
+	if f.Op == "" {
+		return operands[f.Leaf]
+	}
+	right := f.Right.eval(c, operands)
+	if f.Left == nil {
+		return c.EvalUnary(f.Op, right)
+	}
+	return c.EvalBinary(f.Left.eval(c, operands), f.Op, right)
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/value"
+)
+
+// arrayCounter is a context that counts the ops applied to arrays,
+// which a fused chain applies only to elements.
+type arrayCounter struct {
+	value.Context
+	arrays int
+}
+
+func (c *arrayCounter) EvalUnary(op string, right value.Value) value.Value {
+	if right.Rank() > 0 {
+		c.arrays++
+	}
+	return c.Context.EvalUnary(op, right)
+}
+
+func (c *arrayCounter) EvalBinary(left value.Value, op string, right value.Value) value.Value {
+	if left.Rank() > 0 || right.Rank() > 0 {
+		c.arrays++
+	}
+	return c.Context.EvalBinary(left, op, right)
+}
+
+// Chains over packed vectors, Arrow columns and matrices are fused.
+func TestFusedArrays(t *testing.T) {
+	conf := new(config.Config)
+	c := &arrayCounter{Context: exec.NewContext(conf)}
+	iota := c.Context.EvalUnary("iota", value.Int(5))
+	if _, ok := iota.(value.PackedVector); !ok {
+		t.Fatalf("iota 5 is %T; want PackedVector", iota)
+	}
+	col := value.NewVector([]value.Value{value.Int(3), value.Int(1), value.Int(4), value.Int(1), value.Int(5)}).ToArrowCol(memory.DefaultAllocator)
+	defer col.Release()
+	arrow := value.NewArrowVector(col, conf, value.NewChunkResolver(col))
+	matrix := c.Context.EvalBinary(value.NewIntVector([]int{2, 2}), "rho", iota)
+
+	// x*x+y
+	leaf0 := &value.Fused{Leaf: 0}
+	tree := &value.Fused{Op: "+", Left: &value.Fused{Op: "*", Left: leaf0, Right: leaf0}, Right: &value.Fused{Leaf: 1}}
+	tests := []struct {
+		name string
+		x, y value.Value
+		want string
+	}{
+		{"iota", iota, value.Int(1), "2 5 10 17 26"},
+		{"arrow", arrow, iota, "10 3 19 5 30"},
+		{"matrix", matrix, value.Int(1), " 2  5\n10 17"},
+	}
+	for _, test := range tests {
+		c.arrays = 0
+		v := value.EvalFused(c, tree, []value.Value{test.x, test.y})
+		if got := v.Sprint(conf); got != test.want {
+			t.Errorf("%s: got %s; want %s", test.name, got, test.want)
+		}
+		if c.arrays > 0 {
+			t.Errorf("%s: not fused: %d ops applied to arrays", test.name, c.arrays)
+		}
+	}
+
+	// In float64 mode, only chains over floats are fused.
+	conf.SetFloat64(true)
+	floats := value.NewVector([]value.Value{value.Float64(0.5), value.Float64(1.5)})
+	for _, test := range []struct {
+		name  string
+		x     value.Value
+		fused bool
+	}{
+		{"floats", floats, true},
+		{"ints", iota, false},
+	} {
+		c.arrays = 0
+		value.EvalFused(c, tree, []value.Value{test.x, value.Float64(1)})
+		if fused := c.arrays == 0; fused != test.fused {
+			t.Errorf("float64 %s: fused is %t; want %t", test.name, fused, test.fused)
+		}
+	}
+}
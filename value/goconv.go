@@ -0,0 +1,297 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// FromGo converts a Go value into an ivy Value. It accepts
+//
+//	integers of any size, and bool (as 1 or 0)
+//	float32 and float64, which must be finite
+//	complex64 and complex128, whose parts must be finite
+//	*big.Int, *big.Rat, and *big.Float, which are copied
+//	string, which becomes a char or a vector of chars
+//	slices and arrays of any of these, which become vectors
+//	nested slices and arrays of equal length, which become matrices
+//	Value, which is returned unchanged
+//
+// Numbers are stored in the smallest type that holds them exactly,
+// as they are in ivy, so FromGo(2.0) is the integer 2.
+func FromGo(x interface{}) (v Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(Error)
+			if !ok {
+				panic(r)
+			}
+			v, err = nil, e
+		}
+	}()
+	return fromGo(x), nil
+}
+
+// ToGo converts an ivy Value into a Go value. The result is
+//
+//	int for integers that fit in an int, *big.Int for larger ones
+//	*big.Rat for rationals
+//	float64 for floats; it is an error if the value overflows or is NaN
+//	complex128 for complex numbers; it is an error if a part overflows or is NaN
+//	string for chars and vectors of chars
+//	[]interface{} holding the converted elements for other vectors
+//	nested []interface{} for matrices; the lengths at each
+//	level of nesting are the matrix's shape
+func ToGo(v Value) (x interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(Error)
+			if !ok {
+				panic(r)
+			}
+			x, err = nil, e
+		}
+	}()
+	return toGo(v), nil
+}
+
+func fromGo(x interface{}) Value {
+	if rv := reflect.ValueOf(x); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		Errorf("cannot convert nil %T to ivy value", x)
+	}
+	switch x := x.(type) {
+	case nil:
+		Errorf("cannot convert nil to ivy value")
+	case Value:
+		return x
+	case bool:
+		if x {
+			return one
+		}
+		return zero
+	case int:
+		return fromGoInt(int64(x))
+	case int8:
+		return Int(x)
+	case int16:
+		return Int(x)
+	case int32:
+		return Int(x)
+	case int64:
+		return fromGoInt(x)
+	case uint:
+		return fromGoUint(uint64(x))
+	case uint8:
+		return Int(x)
+	case uint16:
+		return Int(x)
+	case uint32:
+		return fromGoUint(uint64(x))
+	case uint64:
+		return fromGoUint(x)
+	case float32:
+		return fromGoFloat(float64(x))
+	case float64:
+		return fromGoFloat(x)
+	case complex64:
+		return fromGoComplex(complex128(x))
+	case complex128:
+		return fromGoComplex(x)
+	case *big.Int:
+		return BigInt{new(big.Int).Set(x)}.shrink()
+	case *big.Rat:
+		return BigRat{new(big.Rat).Set(x)}.shrink()
+	case *big.Float:
+		if x.IsInf() {
+			Errorf("cannot convert infinity to ivy value")
+		}
+		return BigFloat{new(big.Float).Copy(x)}.shrink()
+	case string:
+		return stringValue(x)
+	}
+	rv := reflect.ValueOf(x)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		shape := goShape(rv)
+		data := goFlatten(make([]Value, 0, size(shape)), rv)
+		if len(shape) == 1 {
+			return NewVector(data)
+		}
+		return NewMatrix(shape, data)
+	}
+	Errorf("cannot convert %T to ivy value", x)
+	return nil
+}
+
+func fromGoInt(i int64) Value {
+	if minInt <= i && i <= maxInt {
+		return Int(i)
+	}
+	return BigInt{big.NewInt(i)}
+}
+
+func fromGoUint(u uint64) Value {
+	if u <= maxInt {
+		return Int(u)
+	}
+	return BigInt{new(big.Int).SetUint64(u)}
+}
+
+func fromGoFloat(f float64) Value {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		Errorf("cannot convert %v to ivy value", f)
+	}
+	return BigFloat{big.NewFloat(f)}.shrink()
+}
+
+func fromGoComplex(c complex128) Value {
+	return newComplex(fromGoFloat(real(c)), fromGoFloat(imag(c))).shrink()
+}
+
+// goShape returns the shape of the nested Go slices or arrays in rv,
+// which must be rectangular.
+func goShape(rv reflect.Value) []int {
+	shape := []int{rv.Len()}
+	if rv.Len() == 0 || !isGoSlice(rv.Index(0)) {
+		return shape
+	}
+	inner := goShape(elem(rv.Index(0)))
+	for i := 1; i < rv.Len(); i++ {
+		e := rv.Index(i)
+		if !isGoSlice(e) || !sameShape(goShape(elem(e)), inner) {
+			Errorf("cannot convert %s to ivy value: not rectangular", rv.Type())
+		}
+	}
+	return append(shape, inner...)
+}
+
+// goFlatten appends the converted scalars in the nested Go slices or arrays
+// in rv to data, in order.
+func goFlatten(data []Value, rv reflect.Value) []Value {
+	for i := 0; i < rv.Len(); i++ {
+		e := rv.Index(i)
+		if isGoSlice(e) {
+			data = goFlatten(data, elem(e))
+			continue
+		}
+		v := fromGo(e.Interface())
+		if v.Rank() != 0 {
+			Errorf("cannot convert %s to ivy value: element %d is not a scalar", rv.Type(), i)
+		}
+		data = append(data, v)
+	}
+	return data
+}
+
+// elem returns the value held by rv if it is an interface.
+func elem(rv reflect.Value) reflect.Value {
+	if rv.Kind() == reflect.Interface {
+		return rv.Elem()
+	}
+	return rv
+}
+
+// isGoSlice reports whether rv holds a slice or array.
+func isGoSlice(rv reflect.Value) bool {
+	switch elem(rv).Kind() {
+	case reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+func toGo(v Value) interface{} {
+	switch v := v.(type) {
+	case Int:
+		if int64(int(v)) != int64(v) {
+			return big.NewInt(int64(v)) // Only on machines with 32-bit ints.
+		}
+		return int(v)
+	case BigInt:
+		if v.IsInt64() && int64(int(v.Int64())) == v.Int64() {
+			return int(v.Int64())
+		}
+		return new(big.Int).Set(v.Int)
+	case BigRat:
+		return new(big.Rat).Set(v.Rat)
+	case BigFloat, Float64:
+		return toGoFloat(v)
+	case Complex:
+		return complex(toGoFloat(v.real), toGoFloat(v.imag))
+	case Char:
+		return string(v)
+	case Vector:
+		if len(v) > 0 && v.AllChars() {
+			return goString(v)
+		}
+		return toGoElems(nil, len(v), func(i int) Value { return v[i] })
+	case PackedVector:
+		return toGo(v.ToVector())
+	case ArrowVector:
+		return toGoElems(nil, v.Len(), v.Get)
+	case *Matrix:
+		return toGoElems(v.shape, size(v.shape), func(i int) Value { return v.data[i] })
+	}
+	Errorf("cannot convert %s to Go value", whichType(v))
+	return nil
+}
+
+// toGoFloat returns the value of the real number v as a float64.
+func toGoFloat(v Value) float64 {
+	var f float64
+	switch v := v.(type) {
+	case Int:
+		return float64(v)
+	case BigInt:
+		f, _ = new(big.Float).SetInt(v.Int).Float64()
+	case BigRat:
+		f, _ = v.Float64()
+	case BigFloat:
+		f, _ = v.Float64()
+	case Float64:
+		f = float64(v)
+	default:
+		Errorf("cannot convert %s to float64", whichType(v))
+	}
+	if math.IsInf(f, 0) {
+		Errorf("value overflows float64")
+	}
+	if math.IsNaN(f) {
+		Errorf("value is not a number")
+	}
+	return f
+}
+
+func goString(v Vector) string {
+	runes := make([]rune, len(v))
+	for i, c := range v {
+		runes[i] = rune(c.(Char))
+	}
+	return string(runes)
+}
+
+// toGoElems returns the n elements retrieved by get as a []interface{},
+// nested according to shape if it has more than one dimension.
+func toGoElems(shape []int, n int, get func(int) Value) []interface{} {
+	if len(shape) > 1 {
+		rows := make([]interface{}, shape[0])
+		stride := 0
+		if shape[0] > 0 {
+			stride = n / shape[0]
+		}
+		for r := range rows {
+			base := r * stride
+			rows[r] = toGoElems(shape[1:], stride, func(i int) Value { return get(base + i) })
+		}
+		return rows
+	}
+	elems := make([]interface{}, n)
+	for i := range elems {
+		elems[i] = toGo(get(i))
+	}
+	return elems
+}
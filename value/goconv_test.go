@@ -0,0 +1,118 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"robpike.io/ivy/config"
+)
+
+var fromGoTests = []struct {
+	in   interface{}
+	want string
+}{
+	{3, "3"},
+	{true, "1"},
+	{int64(1) << 40, "1099511627776"},
+	{uint64(math.MaxUint64), "18446744073709551615"},
+	{2.0, "2"},
+	{0.5, "0.5"},
+	{complex(1, -2), "1j-2"},
+	{big.NewRat(2, 6), "1/3"},
+	{new(big.Int).Lsh(big.NewInt(1), 100), "1267650600228229401496703205376"},
+	{"x", "x"},
+	{"hello", "hello"},
+	{[]int{1, 2, 3}, "1 2 3"},
+	{[]interface{}{1, "x", 0.5}, "1 x 0.5"},
+	{[][]int{{1, 2, 3}, {4, 5, 6}}, "1 2 3\n4 5 6"},
+	{[2][1][2]float64{{{1, 2}}, {{3, 4}}}, "1 2\n\n3 4"},
+}
+
+func TestFromGo(t *testing.T) {
+	conf := new(config.Config)
+	for _, test := range fromGoTests {
+		v, err := FromGo(test.in)
+		if err != nil {
+			t.Errorf("FromGo(%#v): %v", test.in, err)
+			continue
+		}
+		if got := v.Sprint(conf); got != test.want {
+			t.Errorf("FromGo(%#v) = %q; want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestFromGoErrors(t *testing.T) {
+	for _, in := range []interface{}{
+		nil,
+		(*big.Int)(nil),
+		(*big.Float)(nil),
+		math.Inf(1),
+		math.NaN(),
+		struct{}{},
+		[][]int{{1, 2}, {3}},
+		[]string{"ab", "cd"},
+	} {
+		if v, err := FromGo(in); err == nil {
+			t.Errorf("FromGo(%#v) = %v; want error", in, v)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 100)
+	tests := []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{3, 3},
+		{int64(1) << 40, 1 << 40},
+		{huge, huge},
+		{big.NewRat(1, 3), big.NewRat(1, 3)},
+		{0.25, 0.25},
+		{complex(1, 0.5), complex(1, 0.5)},
+		{"x", "x"},
+		{"hello", "hello"},
+		{[]float64{1, 2.5}, []interface{}{1, 2.5}},
+		{[][]int{{1, 2}, {3, 4}}, []interface{}{[]interface{}{1, 2}, []interface{}{3, 4}}},
+		{[][]int{}, []interface{}{}},
+	}
+	for _, test := range tests {
+		v, err := FromGo(test.in)
+		if err != nil {
+			t.Fatalf("FromGo(%#v): %v", test.in, err)
+		}
+		got, err := ToGo(v)
+		if err != nil {
+			t.Errorf("ToGo(%v): %v", v, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ToGo(%v) = %#v; want %#v", v, got, test.want)
+		}
+	}
+}
+
+func TestToGoOverflow(t *testing.T) {
+	f := new(big.Float).SetMantExp(big.NewFloat(1), 5000)
+	if x, err := ToGo(BigFloat{f}); err == nil {
+		t.Errorf("ToGo(2**5000) = %v; want error", x)
+	}
+	if x, err := ToGo(newComplex(one, BigFloat{f})); err == nil {
+		t.Errorf("ToGo(1j2**5000) = %v; want error", x)
+	}
+	for _, v := range []Value{Float64(math.Inf(1)), Float64(math.Inf(-1)), Float64(math.NaN()), newComplex(one, Float64(math.NaN()))} {
+		if x, err := ToGo(v); err == nil {
+			t.Errorf("ToGo(%v) = %v; want error", v, x)
+		}
+	}
+	if x, err := ToGo(Float64(0.5)); err != nil || x != 0.5 {
+		t.Errorf("ToGo(Float64(0.5)) = %v, %v; want 0.5", x, err)
+	}
+}
@@ -48,6 +48,9 @@ func (ix *indexState) init(context Context, top, left Expr, index []Expr) {
 		case Vector:
 			ix.indexes[i] = x
 			ix.outShape = append(ix.outShape, len(x))
+		case PackedVector:
+			ix.indexes[i] = x.ToVector()
+			ix.outShape = append(ix.outShape, x.Len())
 		case *Matrix:
 			ix.indexes[i] = x.Data()
 			// Append shape in reverse, because ix.shape will be reversed below.
@@ -84,6 +87,9 @@ func (ix *indexState) init(context Context, top, left Expr, index []Expr) {
 	case Vector:
 		ix.slice = lhs
 		ix.shape = []int{len(lhs)}
+	case PackedVector:
+		ix.slice = lhs.ToVector()
+		ix.shape = []int{lhs.Len()}
 	case ArrowVector:
 		ix.slice = lhs.ToVector()
 		ix.shape = []int{lhs.Len()}
@@ -195,6 +201,9 @@ func IndexAssign(context Context, top, left Expr, index []Expr, right Expr, rhs
 	// RHS must be scalar or have same shape as indexed expression.
 	var rscalar Value
 	var rslice []Value
+	if p, ok := rhs.(PackedVector); ok {
+		rhs = p.ToVector()
+	}
 	switch rhs := rhs.(type) {
 	default:
 		rscalar = rhs
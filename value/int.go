@@ -150,14 +150,21 @@ func (i Int) toType(op string, conf *config.Config, which valueType) Value {
 		return bigRatInt64(int64(i))
 	case bigFloatType:
 		return bigFloatInt64(conf, int64(i))
+	case float64Type:
+		return Float64(i)
 	case complexType:
 		return newComplex(i, Int(0))
 	case vectorType:
 		return NewVector([]Value{i})
+	case packedVectorType:
+		return newPackedInts([]int64{int64(i)})
 	case matrixType:
 		return NewMatrix([]int{1}, []Value{i})
 	case arrowVectorType:
 		return NewVector([]Value{i})
+	case boxType:
+		// The operator will apply to the contents of the box.
+		return i
 	}
 	Errorf("%s: cannot convert int to %s", op, which)
 	return nil
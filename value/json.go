@@ -0,0 +1,361 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"robpike.io/ivy/config"
+)
+
+// The JSON representation of values is:
+//
+//	int                 number without fraction or exponent: 3
+//	rational            object holding the rational as a string: {"rat": "1/3"}
+//	float               number with fraction or exponent: 1.5, 2.0, 1e100
+//	complex             object holding the parts: {"re": 1, "im": {"rat": "1/2"}}
+//	char                string holding the char: "x"
+//	vector of chars     string: "hello"
+//	other vector        array of elements: [1, 2.5, "x"]
+//	matrix              object holding the shape and the elements
+//	                    as nested arrays: {"shape": [2, 2], "data": [[1, 2], [3, 4]]}
+//
+// When decoding, true and false become 1 and 0, and an array of equal-length
+// arrays (to any depth) is a matrix even without the enclosing object.
+
+// jsonText implements the json unary operator.
+func jsonText(c Context, v Value) Value {
+	var b bytes.Buffer
+	encodeJSON(c.Config(), &b, v)
+	str := b.String()
+	elem := make([]Value, 0, utf8.RuneCountInString(str))
+	for _, r := range str {
+		elem = append(elem, Char(r))
+	}
+	return NewVector(elem)
+}
+
+// fromJSON implements the fromjson unary operator.
+func fromJSON(c Context, v Value) Value {
+	var str string
+	switch v := v.(type) {
+	case Char:
+		str = string(v)
+	case Vector:
+		if !v.AllChars() {
+			Errorf("fromjson: value is not a vector of char")
+		}
+		str = v.makeString(c.Config(), false)
+	default:
+		Errorf("fromjson: value is not a vector of char")
+	}
+	return decodeJSON(c.Config(), []byte(str))
+}
+
+// EncodeJSON returns the JSON representation of the value.
+func EncodeJSON(conf *config.Config, v Value) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(Error)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+	var b bytes.Buffer
+	encodeJSON(conf, &b, v)
+	return b.Bytes(), nil
+}
+
+// DecodeJSON returns the value represented by the JSON text.
+func DecodeJSON(conf *config.Config, data []byte) (v Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(Error)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+	return decodeJSON(conf, data), nil
+}
+
+func encodeJSON(conf *config.Config, b *bytes.Buffer, v Value) {
+	switch v := v.(type) {
+	case Int:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case BigInt:
+		b.WriteString(v.Int.String())
+	case BigRat:
+		fmt.Fprintf(b, `{"rat": "%s"}`, v.Rat.String())
+	case BigFloat:
+		if v.IsInf() {
+			Errorf("json: cannot represent infinity")
+		}
+		str := v.Text('g', -1)
+		if !strings.ContainsAny(str, ".e") {
+			str += ".0"
+		}
+		b.WriteString(str)
+	case Float64:
+		if math.IsInf(float64(v), 0) || math.IsNaN(float64(v)) {
+			Errorf("json: cannot represent %s", v.Sprint(conf))
+		}
+		str := strconv.FormatFloat(float64(v), 'g', -1, 64)
+		if !strings.ContainsAny(str, ".e") {
+			str += ".0"
+		}
+		b.WriteString(str)
+	case Complex:
+		b.WriteString(`{"re": `)
+		encodeJSON(conf, b, v.real)
+		b.WriteString(`, "im": `)
+		encodeJSON(conf, b, v.imag)
+		b.WriteString("}")
+	case Char:
+		encodeJSONString(b, string(v))
+	case Vector:
+		if len(v) > 0 && v.AllChars() {
+			encodeJSONString(b, v.makeString(conf, false))
+			return
+		}
+		encodeJSONElems(conf, b, nil, len(v), func(i int) Value { return v[i] })
+	case PackedVector:
+		encodeJSON(conf, b, v.ToVector())
+	case ArrowVector:
+		encodeJSONElems(conf, b, nil, v.Len(), v.Get)
+	case *Matrix:
+		b.WriteString(`{"shape": [`)
+		for i, n := range v.shape {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(strconv.Itoa(n))
+		}
+		b.WriteString(`], "data": `)
+		encodeJSONElems(conf, b, v.shape, size(v.shape), func(i int) Value { return v.data[i] })
+		b.WriteString("}")
+	default:
+		Errorf("json: cannot represent %s", whichType(v))
+	}
+}
+
+func encodeJSONString(b *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s) // Cannot fail.
+	b.Write(data)
+}
+
+// encodeJSONElems writes the n elements retrieved by get as a JSON array,
+// nested according to shape if it has more than one dimension.
+func encodeJSONElems(conf *config.Config, b *bytes.Buffer, shape []int, n int, get func(int) Value) {
+	if len(shape) > 1 {
+		rows := shape[0]
+		stride := 0
+		if rows > 0 {
+			stride = n / rows
+		}
+		b.WriteString("[")
+		for r := 0; r < rows; r++ {
+			if r > 0 {
+				b.WriteString(", ")
+			}
+			base := r * stride
+			encodeJSONElems(conf, b, shape[1:], stride, func(i int) Value { return get(base + i) })
+		}
+		b.WriteString("]")
+		return
+	}
+	b.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		encodeJSON(conf, b, get(i))
+	}
+	b.WriteString("]")
+}
+
+func decodeJSON(conf *config.Config, data []byte) Value {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x interface{}
+	if err := dec.Decode(&x); err != nil {
+		Errorf("fromjson: %v", err)
+	}
+	if dec.More() {
+		Errorf("fromjson: extra text after value")
+	}
+	return jsonValue(conf, x)
+}
+
+// jsonValue converts the result of decoding JSON into a Value.
+func jsonValue(conf *config.Config, x interface{}) Value {
+	switch x := x.(type) {
+	case json.Number:
+		return jsonNumber(conf, string(x))
+	case bool:
+		if x {
+			return one
+		}
+		return zero
+	case string:
+		return stringValue(x)
+	case []interface{}:
+		if shape := jsonShape(x); len(shape) > 1 {
+			data := make([]Value, 0, size(shape))
+			return NewMatrix(shape, jsonFlatten(conf, data, x))
+		}
+		elems := make([]Value, len(x))
+		for i, e := range x {
+			elems[i] = jsonScalar(conf, e)
+		}
+		return NewVector(elems)
+	case map[string]interface{}:
+		return jsonObject(conf, x)
+	case nil:
+		Errorf("fromjson: cannot represent null")
+	}
+	Errorf("fromjson: cannot represent %T", x)
+	return nil
+}
+
+// jsonScalar converts x, which must represent a scalar, into a Value.
+func jsonScalar(conf *config.Config, x interface{}) Value {
+	v := jsonValue(conf, x)
+	if v.Rank() != 0 {
+		Errorf("fromjson: array element is not a scalar: %s", v.Sprint(conf))
+	}
+	return v
+}
+
+func jsonNumber(conf *config.Config, s string) Value {
+	if strings.ContainsAny(s, ".eE") {
+		f, _, err := big.ParseFloat(s, 10, conf.FloatPrec(), big.ToNearestEven)
+		if err != nil {
+			Errorf("fromjson: bad number %s", s)
+		}
+		return BigFloat{f}.shrink()
+	}
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		Errorf("fromjson: bad number %s", s)
+	}
+	return BigInt{i}.shrink()
+}
+
+// stringValue returns the chars of s as a Char or a Vector.
+func stringValue(s string) Value {
+	elems := make([]Value, 0, len(s))
+	for _, r := range s {
+		elems = append(elems, Char(r))
+	}
+	if len(elems) == 1 {
+		return elems[0]
+	}
+	return NewVector(elems)
+}
+
+func jsonObject(conf *config.Config, obj map[string]interface{}) Value {
+	has := func(keys ...string) bool {
+		if len(obj) != len(keys) {
+			return false
+		}
+		for _, k := range keys {
+			if _, ok := obj[k]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case has("rat"):
+		s, ok := obj["rat"].(string)
+		if !ok {
+			Errorf("fromjson: rat value must be a string")
+		}
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			Errorf("fromjson: bad rational %q", s)
+		}
+		return BigRat{r}.shrink()
+	case has("re", "im"):
+		re := jsonScalar(conf, obj["re"])
+		im := jsonScalar(conf, obj["im"])
+		return newComplex(re, im).shrink()
+	case has("shape", "data"):
+		dims, ok := obj["shape"].([]interface{})
+		if !ok {
+			Errorf("fromjson: shape must be an array")
+		}
+		shape := make([]int, len(dims))
+		for i, d := range dims {
+			n, ok := jsonNumber(conf, fmt.Sprint(d)).(Int)
+			if !ok || n < 0 {
+				Errorf("fromjson: bad shape %v", dims)
+			}
+			shape[i] = int(n)
+		}
+		var data []Value
+		switch x := obj["data"].(type) {
+		case []interface{}:
+			data = jsonFlatten(conf, nil, x)
+		case string:
+			data = stringValue(x).toType("fromjson", conf, vectorType).(Vector)
+		default:
+			Errorf("fromjson: data must be an array")
+		}
+		if len(data) != size(shape) {
+			Errorf("fromjson: data does not match shape %v", shape)
+		}
+		if len(shape) == 1 {
+			return NewVector(data)
+		}
+		return NewMatrix(shape, data)
+	}
+	Errorf("fromjson: unrecognized object")
+	return nil
+}
+
+// jsonShape returns the shape of the nested JSON arrays. It is an error
+// if they are not rectangular.
+func jsonShape(x []interface{}) []int {
+	shape := []int{len(x)}
+	if len(x) == 0 {
+		return shape
+	}
+	first, ok := x[0].([]interface{})
+	if !ok {
+		return shape
+	}
+	inner := jsonShape(first)
+	for _, e := range x[1:] {
+		a, ok := e.([]interface{})
+		if !ok || !sameShape(jsonShape(a), inner) {
+			Errorf("fromjson: array is not rectangular")
+		}
+	}
+	return append(shape, inner...)
+}
+
+// jsonFlatten appends the scalars in the nested JSON arrays to data, in order.
+func jsonFlatten(conf *config.Config, data []Value, x []interface{}) []Value {
+	for _, e := range x {
+		if a, ok := e.([]interface{}); ok {
+			data = jsonFlatten(conf, data, a)
+			continue
+		}
+		data = append(data, jsonScalar(conf, e))
+	}
+	return data
+}
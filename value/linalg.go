@@ -0,0 +1,241 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math/big"
+
+	"robpike.io/ivy/config"
+)
+
+// Linear algebra: matrix inverse and matrix divide.
+// The arithmetic is done with the ordinary ivy operators, so it is
+// exact when the elements are exact and is done in floating point,
+// at the configured precision, when any of them is a float.
+
+// inverse implements unary inv. For a square matrix it is the
+// inverse; for a matrix with more rows than columns it is the
+// left pseudo-inverse. A vector is treated as a single column
+// and a scalar as a 1×1 matrix.
+func inverse(c Context, v Value) Value {
+	a, m, n := asColumns("inv", v)
+	identity := make([]Value, m*m)
+	for i := range identity {
+		identity[i] = zero
+	}
+	for i := 0; i < m; i++ {
+		identity[i*m+i] = one
+	}
+	x := leastSquares(c, "inv", a, m, n, identity, m)
+	switch v.(type) {
+	case *Matrix:
+		return NewMatrix([]int{n, m}, x)
+	case Vector, ArrowVector:
+		return NewVector(x)
+	}
+	return x[0]
+}
+
+// matrixDivide implements binary mdiv: u mdiv v is the solution x
+// of the linear system u x = v, or the least-squares solution if u
+// has more rows than columns. A vector is treated as a single column
+// and a scalar as a 1×1 matrix.
+func matrixDivide(c Context, u, v Value) Value {
+	a, m, n := asColumns("mdiv", u)
+	b, mb, k := asColumns("mdiv", v)
+	if m != mb {
+		Errorf("mdiv: mismatched shapes %s and %s", NewIntVector([]int{m, n}), NewIntVector([]int{mb, k}))
+	}
+	x := leastSquares(c, "mdiv", a, m, n, b, k)
+	switch v := v.(type) {
+	case *Matrix:
+		if v.Rank() > 1 {
+			return NewMatrix([]int{n, k}, x)
+		}
+	case Vector, ArrowVector:
+	default:
+		if n == 1 {
+			return x[0]
+		}
+	}
+	return NewVector(x)
+}
+
+// asColumns returns the elements of v, stored by rows, and the number
+// of rows and columns they form.
+func asColumns(op string, v Value) (data []Value, rows, cols int) {
+	switch v := v.(type) {
+	case Char:
+		Errorf("%s: non-numeric argument", op)
+	case Vector:
+		return v, len(v), 1
+	case ArrowVector:
+		vec := v.ToVector()
+		return vec, len(vec), 1
+	case *Matrix:
+		switch v.Rank() {
+		case 1:
+			return v.data, v.shape[0], 1
+		case 2:
+			return v.data, v.shape[0], v.shape[1]
+		}
+		Errorf("%s: matrix must have rank 2", op)
+	}
+	return []Value{v}, 1, 1
+}
+
+// leastSquares returns the n×k solution x of a x = b, where a is m×n
+// and b is m×k. If m > n, it solves the normal equations aᴴa x = aᴴb,
+// where aᴴ is the conjugate transpose of a. Forming them squares the
+// condition number, but keeps the solution exact for exact elements.
+func leastSquares(c Context, op string, a []Value, m, n int, b []Value, k int) []Value {
+	if m == 0 || n == 0 {
+		Errorf("%s: empty matrix", op)
+	}
+	if m < n {
+		Errorf("%s: more unknowns than equations (%d×%d matrix)", op, m, n)
+	}
+	if m > n {
+		a, b = adjointProduct(c, a, m, n, a, n), adjointProduct(c, a, m, n, b, k)
+	}
+	return solve(c, op, a, n, b, k)
+}
+
+// adjointProduct returns aᴴb, where a is m×n and b is m×k.
+func adjointProduct(c Context, a []Value, m, n int, b []Value, k int) []Value {
+	r := make([]Value, n*k)
+	pfor(safeBinary("+") && safeBinary("*"), m, len(r), func(lo, hi int) {
+		for x := lo; x < hi; x++ {
+			i, j := x/k, x%k
+			acc := c.EvalBinary(conjugate(c, a[i]), "*", b[j])
+			for t := 1; t < m; t++ {
+				acc = c.EvalBinary(acc, "+", c.EvalBinary(conjugate(c, a[t*n+i]), "*", b[t*k+j]))
+			}
+			r[x] = acc
+		}
+	})
+	return r
+}
+
+// conjugate returns the complex conjugate of v, which is v itself
+// if v is real.
+func conjugate(c Context, v Value) Value {
+	if z, ok := v.(Complex); ok {
+		return newComplex(z.real, c.EvalUnary("-", z.imag)).shrink()
+	}
+	return v
+}
+
+// solve returns the n×k solution x of a x = b, where a is n×n and b is
+// n×k, using Gauss-Jordan elimination with partial pivoting.
+func solve(c Context, op string, a []Value, n int, b []Value, k int) []Value {
+	conf := c.Config()
+	w := n + k
+	rows := make([][]Value, n)
+	for i := range rows {
+		row := make([]Value, w)
+		copy(row, a[i*n:(i+1)*n])
+		copy(row[n:], b[i*k:(i+1)*k])
+		rows[i] = row
+	}
+	tol := tolerance(conf, op, a, n)
+	for col := 0; col < n; col++ {
+		// Use the largest remaining element in the column as the pivot.
+		p := col
+		pmag := magnitude(conf, op, rows[col][col])
+		for r := col + 1; r < n; r++ {
+			if mag := magnitude(conf, op, rows[r][col]); mag.Cmp(pmag) > 0 {
+				p, pmag = r, mag
+			}
+		}
+		if pmag.Sign() == 0 || tol != nil && pmag.Cmp(tol) <= 0 {
+			Errorf("%s: matrix is singular", op)
+		}
+		rows[col], rows[p] = rows[p], rows[col]
+		pivotRow := rows[col]
+		pivot := pivotRow[col]
+		for j := col; j < w; j++ {
+			pivotRow[j] = c.EvalBinary(pivotRow[j], "/", pivot)
+		}
+		pfor(safeBinary("-") && safeBinary("*"), w, n, func(lo, hi int) {
+			for r := lo; r < hi; r++ {
+				row := rows[r]
+				if r == col || isZero(row[col]) {
+					continue
+				}
+				f := row[col]
+				for j := col; j < w; j++ {
+					row[j] = c.EvalBinary(row[j], "-", c.EvalBinary(f, "*", pivotRow[j]))
+				}
+			}
+		})
+	}
+	x := make([]Value, n*k)
+	for i, row := range rows {
+		copy(x[i*k:], row[n:])
+	}
+	return x
+}
+
+// magnitude returns a measure of the size of v for choosing pivots:
+// the absolute value of a real number and the sum of the absolute
+// values of the parts of a complex number.
+func magnitude(conf *config.Config, op string, v Value) *big.Float {
+	f := new(big.Float).SetPrec(conf.FloatPrec())
+	switch v := v.(type) {
+	case Int:
+		f.SetInt64(int64(v))
+	case BigInt:
+		f.SetInt(v.Int)
+	case BigRat:
+		f.SetRat(v.Rat)
+	case BigFloat:
+		f.Set(v.Float)
+	case Float64:
+		f.SetFloat64(float64(v))
+	case Complex:
+		return f.Add(magnitude(conf, op, v.real), magnitude(conf, op, v.imag))
+	default:
+		Errorf("%s: non-numeric element %s", op, v.Sprint(conf))
+	}
+	return f.Abs(f)
+}
+
+// tolerance returns the size below which a pivot is considered zero,
+// or nil if the elements of the n×n matrix a are all exact.
+func tolerance(conf *config.Config, op string, a []Value, n int) *big.Float {
+	inexact := false
+	max := new(big.Float)
+	for _, v := range a {
+		if isFloat(v) {
+			inexact = true
+		}
+		if mag := magnitude(conf, op, v); mag.Cmp(max) > 0 {
+			max = mag
+		}
+	}
+	if !inexact {
+		return nil
+	}
+	// Allow for the rounding error accumulated during elimination,
+	// which in float64 mode is done with 53-bit mantissas.
+	prec := conf.FloatPrec()
+	if conf.Float64() {
+		prec = 53
+	}
+	tol := new(big.Float).SetPrec(conf.FloatPrec()).SetMantExp(big.NewFloat(float64(n)), 16-int(prec))
+	return tol.Mul(tol, max)
+}
+
+// isFloat reports whether v is, or has a part that is, a float.
+func isFloat(v Value) bool {
+	switch v := v.(type) {
+	case BigFloat, Float64:
+		return true
+	case Complex:
+		return isFloat(v.real) || isFloat(v.imag)
+	}
+	return false
+}
@@ -106,6 +106,10 @@ func bigIntLog(b, v BigInt) (i Int, r BigInt) {
 
 // floatLog computes natural log(x) using the Maclaurin series for log(1-x).
 func floatLog(c Context, x *big.Float) *big.Float {
+	if x.Sign() == 0 && c.Config().Float64() {
+		// As in IEEE arithmetic, log 0 is -Inf.
+		return newFloat(c).SetInf(true)
+	}
 	if x.Sign() <= 0 {
 		Errorf("log of non-positive value")
 	}
@@ -128,7 +132,7 @@ func floatLog(c Context, x *big.Float) *big.Float {
 	mantissa := newFloat(c)
 	exp2 := x.MantExp(mantissa)
 	exp := newFloat(c).SetInt64(int64(exp2))
-	exp.Mul(exp, floatLog2)
+	exp.Mul(exp, constsFor(c.Config()).log2)
 	if invert {
 		exp.Neg(exp)
 	}
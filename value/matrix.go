@@ -135,6 +135,9 @@ func (m *Matrix) Sprint(conf *config.Config) string {
 		if nrows == 0 || ncols == 0 {
 			return ""
 		}
+		if hasBoxes(m.data) {
+			return boxedGrid(conf, m.data, nrows, ncols)
+		}
 		// If it's all chars, print it without padding or quotes.
 		if m.data.AllChars() {
 			for i := 0; i < nrows; i++ {
@@ -159,6 +162,16 @@ func (m *Matrix) Sprint(conf *config.Config) string {
 		}
 		m.write2d(&b, strs, wid)
 	case 3:
+		if hasBoxes(m.data) {
+			n := int(m.ElemSize())
+			for i := 0; i < m.shape[0]; i++ {
+				if i > 0 {
+					b.WriteString("\n\n")
+				}
+				b.WriteString(boxedGrid(conf, m.data[i*n:(i+1)*n], m.shape[1], m.shape[2]))
+			}
+			break
+		}
 		// If it's all chars, print it without padding or quotes.
 		if m.data.AllChars() {
 			nelems := m.shape[0]
@@ -736,6 +749,9 @@ func (m *Matrix) drop(c Context, v Vector) *Matrix {
 // grade returns as a Vector the indexes that sort the rows of m
 // into increasing order.
 func (m *Matrix) grade(c Context) Vector {
+	if hasBoxes(m.data) {
+		Errorf("cannot grade boxes")
+	}
 	x := make([]int, m.shape[0])
 	for i := range x {
 		x[i] = i
@@ -0,0 +1,586 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"sync/atomic"
+
+	"robpike.io/ivy/config"
+)
+
+// packedKind identifies the element type of a PackedVector.
+type packedKind int
+
+const (
+	packedInt   packedKind = iota // Ints, held as int64s.
+	packedFloat                   // Float64s, held as float64s.
+	packedBool                    // Truth values 0 and 1, held as bits.
+	packedChar                    // Chars, held as runes.
+)
+
+// PackedVector is a vector whose elements all have the same simple
+// type, held unboxed rather than as a Value each. Elementwise
+// arithmetic, comparisons and logic on packed vectors run as tight
+// loops over the elements; other operations see a PackedVector as the
+// equivalent Vector. Comparisons and logic ops on vectors of any kind,
+// including Arrow columns, give packed truth values.
+//
+// Exactly one of the element fields is used, as determined by kind.
+// A PackedVector is never empty.
+type PackedVector struct {
+	kind   packedKind
+	ints   []int64
+	floats []float64
+	bits   bitVector
+	chars  []rune
+}
+
+func newPackedInts(x []int64) PackedVector {
+	return PackedVector{kind: packedInt, ints: x}
+}
+
+func newPackedFloats(x []float64) PackedVector {
+	return PackedVector{kind: packedFloat, floats: x}
+}
+
+func newPackedBools(x bitVector) PackedVector {
+	return PackedVector{kind: packedBool, bits: x}
+}
+
+func newPackedChars(x []rune) PackedVector {
+	return PackedVector{kind: packedChar, chars: x}
+}
+
+// pack returns v as a PackedVector, if it is not empty and its
+// elements are all Ints, all Float64s or all Chars.
+func pack(v Vector) (PackedVector, bool) {
+	if len(v) == 0 {
+		return PackedVector{}, false
+	}
+	switch v[0].(type) {
+	case Int:
+		x := make([]int64, len(v))
+		for i, e := range v {
+			e, ok := e.(Int)
+			if !ok {
+				return PackedVector{}, false
+			}
+			x[i] = int64(e)
+		}
+		return newPackedInts(x), true
+	case Float64:
+		x := make([]float64, len(v))
+		for i, e := range v {
+			e, ok := e.(Float64)
+			if !ok {
+				return PackedVector{}, false
+			}
+			x[i] = float64(e)
+		}
+		return newPackedFloats(x), true
+	case Char:
+		x := make([]rune, len(v))
+		for i, e := range v {
+			e, ok := e.(Char)
+			if !ok {
+				return PackedVector{}, false
+			}
+			x[i] = rune(e)
+		}
+		return newPackedChars(x), true
+	}
+	return PackedVector{}, false
+}
+
+// packResult returns v, the result of the elementwise operation op on
+// a packed vector, as a PackedVector if it can be one, so later
+// operations on it can also use tight loops.
+func packResult(op string, v Value) Value {
+	if w, ok := v.(Vector); ok {
+		if p, ok := pack(w); ok {
+			return p
+		}
+	}
+	return v
+}
+
+// truthOp reports whether op is a comparison or logic op, whose
+// results are truth values.
+func truthOp(op string) bool {
+	return packedIntCompareFns[op] != nil || packedLogicFns[op] != nil || op == "not"
+}
+
+// elementwise returns the vector of the n results elem(0), elem(1), ...
+// of the elementwise operation op, computed in parallel if safe, each
+// taking work in proportion to size, as for pfor. The
+// results of a comparison or logic op are written straight into the
+// bits of a packed vector, unless one of them is not 0 or 1.
+func elementwise(safe bool, size int, op string, n int, elem func(k int) Value) Value {
+	if n > 0 && safe && truthOp(op) {
+		var other atomic.Bool
+		bits := newBitVector(n, func(k int) bool {
+			switch elem(k) {
+			case zero:
+				return false
+			case one:
+				return true
+			}
+			other.Store(true)
+			return false
+		})
+		if !other.Load() {
+			return newPackedBools(bits)
+		}
+	}
+	v := make([]Value, n)
+	pfor(safe, size, n, func(lo, hi int) {
+		for k := lo; k < hi; k++ {
+			v[k] = elem(k)
+		}
+	})
+	return NewVector(v)
+}
+
+// unpack returns v, or the equivalent Vector if v is a PackedVector.
+func unpack(v Value) Value {
+	if p, ok := v.(PackedVector); ok {
+		return p.ToVector()
+	}
+	return v
+}
+
+// unpacked returns v, which must be a Vector or a PackedVector, as a Vector.
+func unpacked(v Value) Vector {
+	if p, ok := v.(PackedVector); ok {
+		return p.ToVector()
+	}
+	return v.(Vector)
+}
+
+func (p PackedVector) Get(i int) Value {
+	switch p.kind {
+	case packedInt:
+		return Int(p.ints[i])
+	case packedFloat:
+		return Float64(p.floats[i])
+	case packedBool:
+		return toInt(p.bits.get(i))
+	case packedChar:
+		return Char(p.chars[i])
+	}
+	panic("packed.Get - cannot happen")
+}
+
+func (p PackedVector) Len() int {
+	switch p.kind {
+	case packedInt:
+		return len(p.ints)
+	case packedFloat:
+		return len(p.floats)
+	case packedBool:
+		return p.bits.n
+	case packedChar:
+		return len(p.chars)
+	}
+	panic("packed.Len - cannot happen")
+}
+
+// ToVector returns the elements of p as a Vector.
+func (p PackedVector) ToVector() Vector {
+	elems := make([]Value, p.Len())
+	for i := range elems {
+		elems[i] = p.Get(i)
+	}
+	return NewVector(elems)
+}
+
+func (p PackedVector) String() string {
+	return "(" + p.Sprint(debugConf) + ")"
+}
+
+func (p PackedVector) Sprint(conf *config.Config) string {
+	return p.ToVector().Sprint(conf)
+}
+
+func (p PackedVector) Rank() int {
+	return 1
+}
+
+func (p PackedVector) ProgString() string {
+	// There is no such thing as a vector in program listings; they
+	// are represented as a sliceExpr.
+	panic("packed.ProgString - cannot happen")
+}
+
+func (p PackedVector) Eval(Context) Value {
+	return p
+}
+
+func (p PackedVector) Inner() Value {
+	return p
+}
+
+func (p PackedVector) shrink() Value {
+	if p.Len() == 1 {
+		return p.Get(0)
+	}
+	return p
+}
+
+func (p PackedVector) toType(op string, conf *config.Config, which valueType) Value {
+	switch which {
+	case packedVectorType, arrowVectorType:
+		return p
+	case vectorType:
+		return p.ToVector()
+	case matrixType:
+		return NewMatrix([]int{p.Len()}, p.ToVector())
+	}
+	Errorf("%s: cannot convert packed vector to %s", op, which)
+	return nil
+}
+
+// int64s returns the elements of p as int64s, if they are integers or
+// truth values.
+func (p PackedVector) int64s() ([]int64, bool) {
+	switch p.kind {
+	case packedInt:
+		return p.ints, true
+	case packedBool:
+		x := make([]int64, p.bits.n)
+		p.bits.each(func(i int) {
+			x[i] = 1
+		})
+		return x, true
+	}
+	return nil, false
+}
+
+// float64s returns the elements of p as float64s, if they are numbers.
+func (p PackedVector) float64s() ([]float64, bool) {
+	if p.kind == packedFloat {
+		return p.floats, true
+	}
+	x, ok := p.int64s()
+	if !ok {
+		return nil, false
+	}
+	f := make([]float64, len(x))
+	for i, e := range x {
+		f[i] = float64(e)
+	}
+	return f, true
+}
+
+// truths returns the elements of p as bits, if they are integers or
+// truth values.
+func (p PackedVector) truths() (bitVector, bool) {
+	switch p.kind {
+	case packedBool:
+		return p.bits, true
+	case packedInt:
+		x := p.ints
+		return newBitVector(len(x), func(i int) bool { return x[i] != 0 }), true
+	}
+	return bitVector{}, false
+}
+
+// packedLen returns the length of the result of an elementwise
+// operation on vectors of lengths m and n, either of which may be a
+// scalar in disguise, and whether the lengths are compatible.
+func packedLen(m, n int) (int, bool) {
+	switch {
+	case m == n, n == 1:
+		return m, true
+	case m == 1:
+		return n, true
+	}
+	return 0, false
+}
+
+// packedIntFns are the elementwise binary ops with tight loops for
+// integers. Results outside the range of Int are detected afterwards.
+var packedIntFns = map[string]func(x, y int64) int64{
+	"+": func(x, y int64) int64 { return x + y },
+	"-": func(x, y int64) int64 { return x - y },
+	"*": func(x, y int64) int64 { return x * y },
+	"&": func(x, y int64) int64 { return x & y },
+	"|": func(x, y int64) int64 { return x | y },
+	"^": func(x, y int64) int64 { return x ^ y },
+	"min": func(x, y int64) int64 {
+		if x < y {
+			return x
+		}
+		return y
+	},
+	"max": func(x, y int64) int64 {
+		if x > y {
+			return x
+		}
+		return y
+	},
+}
+
+// packedFloatFns are the analog of packedIntFns for floats.
+var packedFloatFns = map[string]func(x, y float64) float64{
+	"+":   func(x, y float64) float64 { return x + y },
+	"-":   func(x, y float64) float64 { return x - y },
+	"*":   func(x, y float64) float64 { return x * y },
+	"/":   func(x, y float64) float64 { return x / y },
+	"min": math.Min,
+	"max": math.Max,
+}
+
+// packedIntCompareFns are the comparisons of integers and of chars.
+var packedIntCompareFns = map[string]func(x, y int64) bool{
+	"==": func(x, y int64) bool { return x == y },
+	"!=": func(x, y int64) bool { return x != y },
+	"<":  func(x, y int64) bool { return x < y },
+	"<=": func(x, y int64) bool { return x <= y },
+	">":  func(x, y int64) bool { return x > y },
+	">=": func(x, y int64) bool { return x >= y },
+}
+
+// packedFloatCompareFns are the comparisons of floats.
+var packedFloatCompareFns = map[string]func(x, y float64) bool{
+	"==": func(x, y float64) bool { return x == y },
+	"!=": func(x, y float64) bool { return x != y },
+	"<":  func(x, y float64) bool { return x < y },
+	"<=": func(x, y float64) bool { return x <= y },
+	">":  func(x, y float64) bool { return x > y },
+	">=": func(x, y float64) bool { return x >= y },
+}
+
+// packedLogicFns are the logical ops on truth values, applied to 64
+// bits at a time.
+var packedLogicFns = map[string]func(x, y uint64) uint64{
+	"and":  func(x, y uint64) uint64 { return x & y },
+	"or":   func(x, y uint64) uint64 { return x | y },
+	"xor":  func(x, y uint64) uint64 { return x ^ y },
+	"nand": func(x, y uint64) uint64 { return ^(x & y) },
+	"nor":  func(x, y uint64) uint64 { return ^(x | y) },
+}
+
+// packedBinaryOp evaluates u op v elementwise with a tight loop when
+// both operands are packed. It reports false if it cannot, for instance
+// because the op has no such loop or an integer result is too large
+// for an Int.
+func packedBinaryOp(c Context, u Value, op string, v Value) (Value, bool) {
+	p, ok1 := u.(PackedVector)
+	q, ok2 := v.(PackedVector)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	n, ok := packedLen(p.Len(), q.Len())
+	if !ok {
+		return nil, false
+	}
+	if fn := packedLogicFns[op]; fn != nil {
+		x, ok1 := p.truths()
+		y, ok2 := q.truths()
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		z, _ := bitLogic(x, fn, y)
+		return newPackedBools(z), true
+	}
+	switch {
+	case p.kind == packedChar || q.kind == packedChar:
+		fn := packedIntCompareFns[op]
+		if fn == nil || p.kind != q.kind {
+			return nil, false
+		}
+		x := make([]int64, len(p.chars))
+		for i, r := range p.chars {
+			x[i] = int64(r)
+		}
+		y := make([]int64, len(q.chars))
+		for i, r := range q.chars {
+			y[i] = int64(r)
+		}
+		return packedIntCompare(n, x, fn, y), true
+	case p.kind == packedFloat || q.kind == packedFloat:
+		x, _ := p.float64s()
+		y, _ := q.float64s()
+		if fn := packedFloatCompareFns[op]; fn != nil {
+			z := newBitVector(n, func(i int) bool {
+				xi, yi := x[0], y[0]
+				if len(x) > 1 {
+					xi = x[i]
+				}
+				if len(y) > 1 {
+					yi = y[i]
+				}
+				return fn(xi, yi)
+			})
+			return newPackedBools(z), true
+		}
+		fn := packedFloatFns[op]
+		if fn == nil {
+			return nil, false
+		}
+		z := make([]float64, n)
+		pfor(true, 1, n, func(lo, hi int) {
+			xi, yi := x[0], y[0]
+			for i := lo; i < hi; i++ {
+				if len(x) > 1 {
+					xi = x[i]
+				}
+				if len(y) > 1 {
+					yi = y[i]
+				}
+				z[i] = fn(xi, yi)
+			}
+		})
+		return newPackedFloats(z), true
+	}
+	x, _ := p.int64s()
+	y, _ := q.int64s()
+	if fn := packedIntCompareFns[op]; fn != nil {
+		return packedIntCompare(n, x, fn, y), true
+	}
+	fn := packedIntFns[op]
+	if fn == nil {
+		return nil, false
+	}
+	z := make([]int64, n)
+	pfor(true, 1, n, func(lo, hi int) {
+		xi, yi := x[0], y[0]
+		for i := lo; i < hi; i++ {
+			if len(x) > 1 {
+				xi = x[i]
+			}
+			if len(y) > 1 {
+				yi = y[i]
+			}
+			z[i] = fn(xi, yi)
+		}
+	})
+	for _, e := range z {
+		if e < minInt || maxInt < e {
+			return nil, false
+		}
+	}
+	return newPackedInts(z), true
+}
+
+// packedIntCompare returns the n truth values of x fn y.
+func packedIntCompare(n int, x []int64, fn func(x, y int64) bool, y []int64) PackedVector {
+	z := newBitVector(n, func(i int) bool {
+		xi, yi := x[0], y[0]
+		if len(x) > 1 {
+			xi = x[i]
+		}
+		if len(y) > 1 {
+			yi = y[i]
+		}
+		return fn(xi, yi)
+	})
+	return newPackedBools(z)
+}
+
+// packedUnaryOp is the analog of packedBinaryOp for unary ops.
+func packedUnaryOp(c Context, op string, v Value) (Value, bool) {
+	p, ok := v.(PackedVector)
+	if !ok {
+		return nil, false
+	}
+	switch op {
+	case "not":
+		x, ok := p.truths()
+		if !ok {
+			return nil, false
+		}
+		return newPackedBools(x.not()), true
+	case "-", "abs":
+		switch p.kind {
+		case packedFloat:
+			z := make([]float64, len(p.floats))
+			for i, e := range p.floats {
+				if op == "-" {
+					z[i] = -e
+				} else {
+					z[i] = math.Abs(e)
+				}
+			}
+			return newPackedFloats(z), true
+		case packedInt, packedBool:
+			x, _ := p.int64s()
+			z := make([]int64, len(x))
+			for i, e := range x {
+				if op == "-" || e < 0 {
+					e = -e
+				}
+				if maxInt < e {
+					return nil, false // Negated minInt.
+				}
+				z[i] = e
+			}
+			return newPackedInts(z), true
+		}
+	case "sgn":
+		x, ok := p.int64s()
+		if !ok {
+			return nil, false
+		}
+		z := make([]int64, len(x))
+		for i, e := range x {
+			switch {
+			case e > 0:
+				z[i] = 1
+			case e < 0:
+				z[i] = -1
+			}
+		}
+		return newPackedInts(z), true
+	}
+	return nil, false
+}
+
+// packedReduce computes the reduction op/ p with a tight loop for the
+// common associative ops. It reports false if it cannot.
+func packedReduce(c Context, op string, p PackedVector) (Value, bool) {
+	if p.Len() == 0 {
+		return nil, false
+	}
+	switch op {
+	case "and", "or":
+		x, ok := p.truths()
+		if !ok {
+			return nil, false
+		}
+		if op == "and" {
+			return toInt(x.all()), true
+		}
+		return toInt(x.any()), true
+	case "+", "min", "max":
+		if p.kind == packedBool && op == "+" {
+			return fromGoInt(int64(p.bits.count())), true
+		}
+		if p.kind == packedFloat {
+			// Evaluate right to left, as Reduce does, to get the same rounding.
+			fn := packedFloatFns[op]
+			x := p.floats
+			acc := x[len(x)-1]
+			for i := len(x) - 2; i >= 0; i-- {
+				acc = fn(x[i], acc)
+			}
+			return Float64(acc), true
+		}
+		x, ok := p.int64s()
+		if !ok {
+			return nil, false
+		}
+		// The sum of fewer than 1<<31 Ints cannot overflow an int64.
+		fn := packedIntFns[op]
+		acc := x[len(x)-1]
+		for i := len(x) - 2; i >= 0; i-- {
+			acc = fn(x[i], acc)
+		}
+		return fromGoInt(acc), true
+	}
+	return nil, false
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value_test
+
+import (
+	"math/big"
+	"runtime"
+	"testing"
+
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/value"
+)
+
+// Comparisons and logic ops give packed truth values whatever
+// the kind of their operands.
+func TestPackedTruths(t *testing.T) {
+	c := exec.NewContext(new(config.Config))
+	ints := value.NewIntVector([]int{3, 1, 4, 1, 5})
+	rats := value.NewVector([]value.Value{
+		value.BigRat{Rat: big.NewRat(3, 2)},
+		value.BigRat{Rat: big.NewRat(1, 2)},
+	})
+	tests := []struct {
+		name string
+		v    value.Value
+		want string
+	}{
+		{"ints > 2", c.EvalBinary(ints, ">", value.Int(2)), "1 0 1 0 1"},
+		{"rats > 1", c.EvalBinary(rats, ">", value.Int(1)), "1 0"},
+		{"1 == ints", c.EvalBinary(value.Int(1), "==", ints), "0 1 0 1 0"},
+		{"ints and 1", c.EvalBinary(ints, "and", value.Int(1)), "1 1 1 1 1"},
+		{"not ints", c.EvalUnary("not", ints), "0 0 0 0 0"},
+	}
+	for _, test := range tests {
+		if _, ok := test.v.(value.PackedVector); !ok {
+			t.Errorf("%s: got %T; want PackedVector", test.name, test.v)
+		}
+		if got := test.v.Sprint(c.Config()); got != test.want {
+			t.Errorf("%s: got %s; want %s", test.name, got, test.want)
+		}
+	}
+	// Other results of ops on unpacked vectors stay unpacked.
+	if v := c.EvalBinary(ints, "min", value.Int(1)); !isVector(v) {
+		t.Errorf("ints min 1: got %T; want Vector", v)
+	}
+}
+
+// Truth values are written straight into the bitmap, without a boxed
+// result for each element.
+func TestPackedTruthsMemory(t *testing.T) {
+	c := exec.NewContext(new(config.Config))
+	const n = 100000
+	// Small elements, which Go does not allocate to box.
+	ints := make([]int, n)
+	for i := range ints {
+		ints[i] = i % 200
+	}
+	v := value.NewIntVector(ints)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	c.EvalBinary(v, "<", value.Int(100))
+	runtime.ReadMemStats(&after)
+	// A boxed result alone would take 16 bytes an element.
+	if bytes := after.TotalAlloc - before.TotalAlloc; bytes > n {
+		t.Errorf("v < 100 allocated %d bytes for %d elements", bytes, n)
+	}
+}
+
+func isVector(v value.Value) bool {
+	_, ok := v.(value.Vector)
+	return ok
+}
+
+// Negating the smallest Int, packed or not, gives a BigInt.
+func TestPackedNegate(t *testing.T) {
+	c := exec.NewContext(new(config.Config))
+	one := c.EvalUnary("iota", value.Int(1))
+	// Build -2**31 by multiplying packed vectors, so it stays packed.
+	x := c.EvalUnary("-", one)
+	for _, n := range []int{2, 4, 16, 256, 65536} {
+		x = c.EvalBinary(c.EvalBinary(one, "*", value.Int(n)), "*", x)
+	}
+	if _, ok := x.(value.PackedVector); !ok {
+		t.Fatalf("%s is %T; want PackedVector", x.Sprint(c.Config()), x)
+	}
+	for _, op := range []string{"-", "abs"} {
+		v := c.EvalUnary(op, x)
+		if e := v.(value.ValueGetter).Get(0); !isBigInt(e) || e.Sprint(c.Config()) != "2147483648" {
+			t.Errorf("%s %s: got %T %s; want BigInt 2147483648", op, x.Sprint(c.Config()), e, e.Sprint(c.Config()))
+		}
+		if e := c.EvalUnary(op, value.Int(-2147483648)); !isBigInt(e) {
+			t.Errorf("%s -2147483648: got %T; want BigInt", op, e)
+		}
+	}
+}
+
+func isBigInt(v value.Value) bool {
+	_, ok := v.(value.BigInt)
+	return ok
+}
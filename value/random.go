@@ -0,0 +1,321 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Random distributions. A generator draws a single seed from the
+// configuration's random number generator, so )seed makes its results
+// reproducible, and derives from it a generator for each block of
+// randBlock elements. The blocks do not depend on how the work is
+// divided, so the results are the same however many are computed in
+// parallel.
+
+const randBlock = 1024
+
+// randomShape returns the shape of the array of random values
+// described by v, a scalar length or a vector shape.
+func randomShape(op string, v Value) []int {
+	var elems Vector
+	switch v := v.(type) {
+	case Int:
+		elems = Vector{v}
+	case Vector:
+		elems = v
+	default:
+		Errorf("%s: shape must be small integers", op)
+	}
+	shape := make([]int, len(elems))
+	n := 1
+	for i, e := range elems {
+		d, ok := e.(Int)
+		if !ok || d < 0 || maxInt < d {
+			Errorf("%s: shape must be small integers", op)
+		}
+		shape[i] = int(d)
+		n *= shape[i]
+		if n > maxInt {
+			Errorf("%s: too many elements", op)
+		}
+	}
+	return shape
+}
+
+// randomArray returns an array of the shape described by v whose
+// elements are produced by gen.
+func randomArray(c Context, op string, v Value, gen func(r *rand.Rand) Value) Value {
+	shape := randomShape(op, v)
+	data := make(Vector, size(shape))
+	seed := uint64(c.Config().Random().Int63())
+	nblocks := (len(data) + randBlock - 1) / randBlock
+	pfor(true, randBlock, nblocks, func(lo, hi int) {
+		for b := lo; b < hi; b++ {
+			r := rand.New(rand.NewSource(int64(seed + uint64(b)*0x9E3779B97F4A7C15)))
+			end := (b + 1) * randBlock
+			if end > len(data) {
+				end = len(data)
+			}
+			for i := b * randBlock; i < end; i++ {
+				data[i] = gen(r)
+			}
+		}
+	})
+	switch len(shape) {
+	case 0:
+		return data[0]
+	case 1:
+		return NewVector(data)
+	}
+	return NewMatrix(shape, data)
+}
+
+// randomParams returns the parameters of a distribution, given by the
+// left operand u, which must have between min and max elements.
+func randomParams(c Context, op string, u Value, min, max int) []float64 {
+	elems := u.(Vector)
+	if len(elems) < min || len(elems) > max {
+		Errorf("%s: wrong number of parameters", op)
+	}
+	params := make([]float64, len(elems))
+	for i, e := range elems {
+		switch e.(type) {
+		case Int, BigInt, BigRat, BigFloat, Float64:
+		default:
+			Errorf("%s: parameter must be a real number", op)
+		}
+		params[i] = toFloat64(c, op, e)
+	}
+	return params
+}
+
+// randomFloat returns the float64 x as a Value.
+func randomFloat(c Context, x float64) Value {
+	return BigFloat{newF(c.Config()).SetFloat64(x)}
+}
+
+// uniform returns floats uniformly distributed over [lo, hi).
+func uniform(c Context, lo, hi float64, v Value) Value {
+	if !(lo < hi) {
+		Errorf("uniform: empty interval")
+	}
+	return randomArray(c, "uniform", v, func(r *rand.Rand) Value {
+		return randomFloat(c, lo+(hi-lo)*r.Float64())
+	})
+}
+
+// normal returns normally distributed floats.
+func normal(c Context, mean, sdev float64, v Value) Value {
+	if sdev < 0 {
+		Errorf("normal: negative standard deviation")
+	}
+	return randomArray(c, "normal", v, func(r *rand.Rand) Value {
+		return randomFloat(c, mean+sdev*r.NormFloat64())
+	})
+}
+
+// expo returns exponentially distributed floats.
+func expo(c Context, rate float64, v Value) Value {
+	if !(rate > 0) {
+		Errorf("expo: rate must be positive")
+	}
+	return randomArray(c, "expo", v, func(r *rand.Rand) Value {
+		return randomFloat(c, r.ExpFloat64()/rate)
+	})
+}
+
+// poisson returns integers with a Poisson distribution of the given mean.
+func poisson(c Context, mean float64, v Value) Value {
+	if !(mean >= 0) || mean > 1<<52 {
+		Errorf("poisson: mean out of range")
+	}
+	return randomArray(c, "poisson", v, func(r *rand.Rand) Value {
+		return fromGoInt(poissonInt(r, mean))
+	})
+}
+
+// poissonInt returns a Poisson variate. For small means it multiplies
+// uniform variates; for larger ones it uses Hörmann's transformed
+// rejection method, PTRS.
+func poissonInt(r *rand.Rand, mean float64) int64 {
+	if mean < 10 {
+		limit := math.Exp(-mean)
+		k := int64(0)
+		for p := r.Float64(); p > limit; p *= r.Float64() {
+			k++
+		}
+		return k
+	}
+	slam := math.Sqrt(mean)
+	loglam := math.Log(mean)
+	b := 0.931 + 2.53*slam
+	a := -0.059 + 0.02483*b
+	invalpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2)
+	for {
+		u := r.Float64() - 0.5
+		v := r.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + mean + 0.43)
+		if us >= 0.07 && v <= vr {
+			return int64(k)
+		}
+		if k < 0 || us < 0.013 && v > us {
+			continue
+		}
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invalpha)-math.Log(a/(us*us)+b) <= -mean+k*loglam-lg {
+			return int64(k)
+		}
+	}
+}
+
+// binomial returns integers with a binomial distribution: the number
+// of successes in n trials, each with probability p.
+func binomial(c Context, n, p float64, v Value) Value {
+	if n < 0 || n != math.Floor(n) || n > 1<<52 {
+		Errorf("binomial: trials must be a non-negative integer")
+	}
+	if !(0 <= p && p <= 1) {
+		Errorf("binomial: probability out of range")
+	}
+	return randomArray(c, "binomial", v, func(r *rand.Rand) Value {
+		return fromGoInt(binomialInt(r, int64(n), p))
+	})
+}
+
+// binomialInt returns a binomial variate. When the expected count is
+// small it counts by inversion. Otherwise it follows Devroye, splitting
+// the trials at the median order statistic of their uniform variates,
+// which has a beta distribution, and recurs on the side holding p.
+func binomialInt(r *rand.Rand, n int64, p float64) int64 {
+	var k int64
+	for n > 0 && p > 0 {
+		if p >= 1 {
+			return k + n
+		}
+		if float64(n)*math.Min(p, 1-p) < 20 {
+			return k + binomialInversion(r, n, p)
+		}
+		i := (n + 1) / 2
+		x := betaFloat(r, float64(i), float64(n+1-i))
+		if x > p {
+			n, p = i-1, p/x
+		} else {
+			k += i
+			n, p = n-i, (p-x)/(1-x)
+		}
+	}
+	return k
+}
+
+// binomialInversion returns a binomial variate by inverting the
+// distribution function, which takes time proportional to n·min(p, 1-p).
+func binomialInversion(r *rand.Rand, n int64, p float64) int64 {
+	flip := p > 0.5
+	if flip {
+		p = 1 - p
+	}
+	q := 1 - p
+	s := p / q
+	a := float64(n+1) * s
+	prob := math.Pow(q, float64(n))
+	u := r.Float64()
+	var k int64
+	for u > prob && k < n {
+		u -= prob
+		k++
+		prob *= a/float64(k) - s
+	}
+	if flip {
+		return n - k
+	}
+	return k
+}
+
+// betaFloat returns a beta variate with parameters a, b >= 1.
+func betaFloat(r *rand.Rand, a, b float64) float64 {
+	x := gammaFloat(r, a)
+	return x / (x + gammaFloat(r, b))
+}
+
+// gammaFloat returns a gamma variate with shape a >= 1 and scale 1,
+// using the method of Marsaglia and Tsang.
+func gammaFloat(r *rand.Rand, a float64) float64 {
+	d := a - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// weightedSample returns indexes chosen at random, with replacement, with
+// probabilities proportional to the weights u.
+func weightedSample(c Context, u, v Value) Value {
+	weights := randomParams(c, "sample", u, 1, maxInt)
+	cum := make([]float64, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		if w < 0 {
+			Errorf("sample: negative weight")
+		}
+		total += w
+		cum[i] = total
+	}
+	if !(total > 0) {
+		Errorf("sample: weights are all zero")
+	}
+	origin := c.Config().Origin()
+	return randomArray(c, "sample", v, func(r *rand.Rand) Value {
+		x := total * r.Float64()
+		i := sort.Search(len(cum), func(i int) bool { return cum[i] > x })
+		if i == len(cum) {
+			i = len(cum) - 1
+		}
+		for weights[i] == 0 {
+			i--
+		}
+		return Int(i + origin)
+	})
+}
+
+// shuffle returns the items of v in random order.
+func shuffle(c Context, v Value) Value {
+	switch v := v.(type) {
+	case Vector:
+		perm := c.Config().Random().Perm(len(v))
+		result := make(Vector, len(v))
+		for i, p := range perm {
+			result[i] = v[p]
+		}
+		return NewVector(result)
+	case ArrowVector:
+		return shuffle(c, v.ToVector())
+	case *Matrix:
+		if v.shape[0] == 0 {
+			return v
+		}
+		perm := c.Config().Random().Perm(v.shape[0])
+		stride := len(v.data) / v.shape[0]
+		result := make(Vector, 0, len(v.data))
+		for _, p := range perm {
+			result = append(result, v.data[p*stride:(p+1)*stride]...)
+		}
+		return NewMatrix(v.shape, result)
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"sort"
+
+	"github.com/apache/arrow/go/v10/arrow"
+)
+
+// ChunkResolver is a Resolver that maps a row index of a chunked
+// arrow column to the chunk holding it and the offset within that chunk.
+// Columns of a table built from the same records share a chunk layout,
+// so one ChunkResolver serves every column of such a table.
+type ChunkResolver struct {
+	offsets []int // offsets[i] is the first row of chunk i; the last entry is the row count.
+}
+
+// NewChunkResolver returns a ChunkResolver for the chunk layout of col.
+func NewChunkResolver(col *arrow.Column) *ChunkResolver {
+	chunks := col.Data().Chunks()
+	offsets := make([]int, len(chunks)+1)
+	for i, chunk := range chunks {
+		offsets[i+1] = offsets[i] + chunk.Len()
+	}
+	return &ChunkResolver{offsets: offsets}
+}
+
+// Resolve returns the chunk and the offset within the chunk of row idx.
+func (r *ChunkResolver) Resolve(idx int) (int, int) {
+	// Find the last chunk whose first row is <= idx.
+	c := sort.Search(len(r.offsets)-1, func(i int) bool {
+		return r.offsets[i+1] > idx
+	})
+	return c, idx - r.offsets[c]
+}
+
+// NumRows returns the number of rows in the column.
+func (r *ChunkResolver) NumRows() int {
+	return r.offsets[len(r.offsets)-1]
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// setKey is a comparable form of a scalar, so the set operations can
+// find matching elements by hashing rather than by comparing pairs.
+// Numerically equal values of different types, such as 1/2 and 0.5,
+// have the same key. The key of a float is its exact binary value, so
+// float 1/10, which is not exactly 1/10, does not match the rational
+// 1/10, although == compares them at the float precision and finds
+// them equal.
+type setKey struct {
+	kind byte   // 'n' for real numbers, 'z' for complex, 'c' for chars, 'b' for anything else.
+	i    int64  // The value of a small integer or char.
+	s    string // The text of any other value.
+}
+
+func (k setKey) text() string {
+	if k.s != "" {
+		return k.s
+	}
+	return strconv.FormatInt(k.i, 10)
+}
+
+// keyOf returns the set key for v.
+func keyOf(v Value) setKey {
+	switch v := v.(type) {
+	case Int:
+		return setKey{kind: 'n', i: int64(v)}
+	case Char:
+		return setKey{kind: 'c', i: int64(v)}
+	case BigInt:
+		return intKey(v.Int)
+	case BigRat:
+		return ratKey(v.Rat)
+	case BigFloat:
+		if v.IsInf() {
+			return setKey{kind: 'n', s: v.String()}
+		}
+		r, _ := v.Rat(nil)
+		return ratKey(r)
+	case Float64:
+		if math.IsInf(float64(v), 0) || math.IsNaN(float64(v)) {
+			return setKey{kind: 'n', s: v.String()}
+		}
+		return ratKey(new(big.Rat).SetFloat64(float64(v)))
+	case Complex:
+		return setKey{kind: 'z', s: keyOf(v.real).text() + "j" + keyOf(v.imag).text()}
+	case Box:
+		return setKey{kind: 'b', s: arrayKey(v.Contents())}
+	case Func:
+		return setKey{kind: 'f', s: v.text}
+	}
+	Errorf("unexpected type %s in set operation", whichType(v))
+	return setKey{}
+}
+
+// arrayKey returns text that identifies the contents of a box.
+func arrayKey(v Value) string {
+	var b strings.Builder
+	switch v := v.(type) {
+	case Vector:
+		fmt.Fprintf(&b, "[%d]", len(v))
+		for _, x := range v {
+			k := keyOf(x)
+			fmt.Fprintf(&b, " %c%s", k.kind, k.text())
+		}
+	case PackedVector:
+		return arrayKey(v.ToVector())
+	case ArrowVector:
+		return arrayKey(v.ToVector())
+	case *Matrix:
+		fmt.Fprintf(&b, "%v", v.shape)
+		b.WriteString(arrayKey(NewVector(v.data)))
+	default:
+		k := keyOf(v)
+		fmt.Fprintf(&b, "%c%s", k.kind, k.text())
+	}
+	return b.String()
+}
+
+func intKey(i *big.Int) setKey {
+	if i.IsInt64() {
+		return setKey{kind: 'n', i: i.Int64()}
+	}
+	return setKey{kind: 'n', s: i.String()}
+}
+
+func ratKey(r *big.Rat) setKey {
+	if r.IsInt() {
+		return intKey(r.Num())
+	}
+	return setKey{kind: 'n', s: r.String()}
+}
+
+// keySet returns the set of keys of the elements of v.
+func keySet(v Vector) map[setKey]bool {
+	set := make(map[setKey]bool, len(v))
+	for _, x := range v {
+		set[keyOf(x)] = true
+	}
+	return set
+}
+
+// unique returns the elements of v with repeats removed, in the
+// order of their first occurrence.
+func unique(v Vector) Vector {
+	seen := make(map[setKey]bool, len(v))
+	elems := make([]Value, 0, len(v))
+	for _, x := range v {
+		if k := keyOf(x); !seen[k] {
+			seen[k] = true
+			elems = append(elems, x)
+		}
+	}
+	return NewVector(elems)
+}
+
+// union returns u followed by the elements of v that are not in u.
+func union(u, v Vector) Vector {
+	elems := append(make([]Value, 0, len(u)+len(v)), u...)
+	return NewVector(append(elems, selectIn(v, keySet(u), false)...))
+}
+
+// selectIn returns the elements of v whose presence in the set is want.
+func selectIn(v Vector, set map[setKey]bool, want bool) []Value {
+	elems := make([]Value, 0, len(v))
+	for _, x := range v {
+		if set[keyOf(x)] == want {
+			elems = append(elems, x)
+		}
+	}
+	return elems
+}
@@ -130,7 +130,7 @@ func sincos(name string, c Context, index int, x *big.Float, z *big.Float, exp u
 func twoPiReduce(c Context, x *big.Float) {
 	// TODO: Is there an easy better algorithm?
 	twoPi := newFloat(c).Set(floatTwo)
-	twoPi.Mul(twoPi, floatPi)
+	twoPi.Mul(twoPi, constsFor(c.Config()).pi)
 	// Do something clever(er) if it's large.
 	if x.Cmp(newFloat(c).SetInt64(1000)) > 0 {
 		multiples := make([]*big.Float, 0, 100)
@@ -0,0 +1,398 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math/big"
+	"sort"
+
+	"robpike.io/ivy/config"
+)
+
+// Statistics. The data for a statistic is a vector, or a column of
+// a matrix, whose elements are real numbers. If they are all exact,
+// the arithmetic is done with rationals and the result is exact;
+// otherwise it is done in floating point.
+
+// sample is the data for a statistic.
+type sample struct {
+	conf   *config.Config
+	rats   []*big.Rat   // The data, if it is exact.
+	floats []*big.Float // The data, if it is not.
+}
+
+// newSample returns the sample holding the elements of v.
+func newSample(conf *config.Config, op string, v []Value) *sample {
+	s := &sample{conf: conf}
+	exact := true
+	for _, x := range v {
+		switch x.(type) {
+		case Int, BigInt, BigRat:
+		case BigFloat, Float64:
+			exact = false
+		default:
+			Errorf("%s: invalid value %s", op, x.Sprint(conf))
+		}
+	}
+	if exact {
+		s.rats = make([]*big.Rat, len(v))
+		for i, x := range v {
+			s.rats[i] = x.toType(op, conf, bigRatType).(BigRat).Rat
+		}
+		return s
+	}
+	s.floats = make([]*big.Float, len(v))
+	for i, x := range v {
+		s.floats[i] = x.toType(op, conf, bigFloatType).(BigFloat).Float
+	}
+	return s
+}
+
+// sampleOf returns the sample holding the elements of the vector v.
+// It reads integer and floating-point columns directly.
+func sampleOf(c Context, op string, v Value) *sample {
+	conf := c.Config()
+	switch v := v.(type) {
+	case Vector:
+		return newSample(conf, op, v)
+	case ArrowVector:
+		if x, ok := v.int64s(); ok {
+			s := &sample{conf: conf, rats: make([]*big.Rat, len(x))}
+			for i, e := range x {
+				s.rats[i] = new(big.Rat).SetInt64(e)
+			}
+			return s
+		}
+		if x, ok := v.float64s(); ok {
+			s := &sample{conf: conf, floats: make([]*big.Float, len(x))}
+			for i, e := range x {
+				s.floats[i] = newF(conf).SetFloat64(e)
+			}
+			return s
+		}
+		return newSample(conf, op, v.ToVector())
+	case *Matrix:
+		Errorf("%s: matrix argument must be paired with a matrix", op)
+	}
+	return newSample(conf, op, []Value{v})
+}
+
+func (s *sample) len() int {
+	if s.rats != nil {
+		return len(s.rats)
+	}
+	return len(s.floats)
+}
+
+func (s *sample) exact() bool {
+	return s.floats == nil
+}
+
+// inexact converts the sample to floating point.
+func (s *sample) inexact() {
+	if !s.exact() {
+		return
+	}
+	s.floats = make([]*big.Float, len(s.rats))
+	for i, r := range s.rats {
+		s.floats[i] = newF(s.conf).SetRat(r)
+	}
+	s.rats = nil
+}
+
+func (s *sample) need(op string, n int) {
+	if s.len() < n {
+		Errorf("%s: need at least %d values", op, n)
+	}
+}
+
+// mean returns the arithmetic mean, as a *big.Rat or *big.Float.
+func (s *sample) mean() interface{} {
+	if s.exact() {
+		sum := new(big.Rat)
+		for _, r := range s.rats {
+			sum.Add(sum, r)
+		}
+		return sum.Quo(sum, new(big.Rat).SetInt64(int64(len(s.rats))))
+	}
+	sum := newF(s.conf)
+	for _, f := range s.floats {
+		sum.Add(sum, f)
+	}
+	return sum.Quo(sum, newF(s.conf).SetInt64(int64(len(s.floats))))
+}
+
+// covariance returns the sample covariance of s and t, which have
+// the same length, dividing by one less than the number of values.
+func covariance(op string, s, t *sample) Value {
+	if s.len() != t.len() {
+		Errorf("%s: length mismatch: %d %d", op, s.len(), t.len())
+	}
+	s.need(op, 2)
+	if !s.exact() || !t.exact() {
+		s.inexact()
+		t.inexact()
+	}
+	n := int64(s.len() - 1)
+	if s.exact() {
+		ms, mt := s.mean().(*big.Rat), t.mean().(*big.Rat)
+		sum, x, y := new(big.Rat), new(big.Rat), new(big.Rat)
+		for i := range s.rats {
+			x.Sub(s.rats[i], ms)
+			y.Sub(t.rats[i], mt)
+			sum.Add(sum, x.Mul(x, y))
+		}
+		return BigRat{sum.Quo(sum, new(big.Rat).SetInt64(n))}.shrink()
+	}
+	ms, mt := s.mean().(*big.Float), t.mean().(*big.Float)
+	sum, x, y := newF(s.conf), newF(s.conf), newF(s.conf)
+	for i := range s.floats {
+		x.Sub(s.floats[i], ms)
+		y.Sub(t.floats[i], mt)
+		sum.Add(sum, x.Mul(x, y))
+	}
+	return BigFloat{sum.Quo(sum, newF(s.conf).SetInt64(n))}.shrink()
+}
+
+// sort sorts the sample into ascending order.
+func (s *sample) sort() {
+	if s.exact() {
+		sort.Slice(s.rats, func(i, j int) bool { return s.rats[i].Cmp(s.rats[j]) < 0 })
+		return
+	}
+	sort.Slice(s.floats, func(i, j int) bool { return s.floats[i].Cmp(s.floats[j]) < 0 })
+}
+
+// quantile returns the p quantile of the sorted sample, 0 <= p <= 1,
+// interpolating linearly between the values at the closest ranks.
+func (s *sample) quantile(op string, p Value) Value {
+	s.need(op, 1)
+	switch p.(type) {
+	case Int, BigInt, BigRat:
+	case BigFloat, Float64:
+		s.inexact()
+	default:
+		Errorf("%s: invalid probability %s", op, p.Sprint(s.conf))
+	}
+	n := s.len()
+	if s.exact() {
+		pr := p.toType(op, s.conf, bigRatType).(BigRat).Rat
+		if pr.Sign() < 0 || pr.Cmp(big.NewRat(1, 1)) > 0 {
+			Errorf("%s: probability %s out of range", op, p.Sprint(s.conf))
+		}
+		h := new(big.Rat).Mul(pr, big.NewRat(int64(n-1), 1))
+		lo := new(big.Int).Quo(h.Num(), h.Denom()).Int64()
+		if lo == int64(n-1) {
+			return BigRat{s.rats[lo]}.shrink()
+		}
+		frac := h.Sub(h, new(big.Rat).SetInt64(lo))
+		x := new(big.Rat).Sub(s.rats[lo+1], s.rats[lo])
+		x.Mul(x, frac)
+		return BigRat{x.Add(x, s.rats[lo])}.shrink()
+	}
+	pf := p.toType(op, s.conf, bigFloatType).(BigFloat).Float
+	if pf.Sign() < 0 || pf.Cmp(big.NewFloat(1)) > 0 {
+		Errorf("%s: probability %s out of range", op, p.Sprint(s.conf))
+	}
+	h := newF(s.conf).Mul(pf, newF(s.conf).SetInt64(int64(n-1)))
+	lo, _ := h.Int64()
+	if lo == int64(n-1) {
+		return BigFloat{newF(s.conf).Set(s.floats[lo])}.shrink()
+	}
+	frac := h.Sub(h, newF(s.conf).SetInt64(lo))
+	x := newF(s.conf).Sub(s.floats[lo+1], s.floats[lo])
+	x.Mul(x, frac)
+	return BigFloat{x.Add(x, s.floats[lo])}.shrink()
+}
+
+func mean(c Context, v Value) Value {
+	s := sampleOf(c, "mean", v)
+	s.need("mean", 1)
+	switch m := s.mean().(type) {
+	case *big.Rat:
+		return BigRat{m}.shrink()
+	case *big.Float:
+		return BigFloat{m}.shrink()
+	}
+	return nil
+}
+
+func median(c Context, v Value) Value {
+	s := sampleOf(c, "median", v)
+	s.sort()
+	return s.quantile("median", BigRat{big.NewRat(1, 2)})
+}
+
+func variance(c Context, v Value) Value {
+	s := sampleOf(c, "var", v)
+	return covariance("var", s, s)
+}
+
+func sdev(c Context, v Value) Value {
+	return c.EvalUnary("sqrt", variance(c, v))
+}
+
+// mode returns the most frequent element of v, the first to
+// occur if there is a tie.
+func mode(c Context, v Value) Value {
+	var m *Matrix
+	if a, ok := v.(ArrowVector); ok {
+		m = countArrow(a)
+	} else {
+		m = count(sampleValues(v))
+	}
+	if m.shape[0] == 0 {
+		Errorf("mode: need at least 1 value")
+	}
+	best := 0
+	for i := 1; i < m.shape[0]; i++ {
+		if m.data[2*i+1].(Int) > m.data[2*best+1].(Int) {
+			best = i
+		}
+	}
+	return m.data[2*best]
+}
+
+// sampleValues returns the elements of the vector or scalar v.
+func sampleValues(v Value) Vector {
+	if v, ok := v.(Vector); ok {
+		return v
+	}
+	return NewVector([]Value{v})
+}
+
+// quantiles returns the p quantiles of v. The probabilities p
+// are a scalar or vector; the result has the same shape.
+func quantiles(c Context, op string, p, v Value) Value {
+	s := sampleOf(c, op, v)
+	s.sort()
+	if p.Rank() == 0 {
+		return s.quantile(op, p)
+	}
+	ps := sampleValues(p.toType(op, c.Config(), vectorType))
+	q := make([]Value, len(ps))
+	for i, x := range ps {
+		// Converting an exact sample is permanent, so use a copy.
+		t := *s
+		q[i] = t.quantile(op, x)
+	}
+	return NewVector(q)
+}
+
+// columns returns the columns of the matrix, the subarrays along its
+// first axis, and the shape of the array they form.
+func columns(m *Matrix) ([]Vector, []int) {
+	n := len(m.data) / m.shape[0]
+	cols := make([]Vector, n)
+	for j := range cols {
+		col := make([]Value, m.shape[0])
+		for i := range col {
+			col[i] = m.data[i*n+j]
+		}
+		cols[j] = col
+	}
+	return cols, m.shape[1:]
+}
+
+// alongFirstAxis applies the statistic f to each column of m,
+// returning the array of results.
+func alongFirstAxis(c Context, m *Matrix, f func(Context, Value) Value) Value {
+	cols, shape := columns(m)
+	results := make([]Value, len(cols))
+	pfor(true, m.shape[0], len(cols), func(lo, hi int) {
+		for j := lo; j < hi; j++ {
+			results[j] = f(c, cols[j])
+		}
+	})
+	if len(shape) == 1 {
+		return NewVector(results)
+	}
+	return NewMatrix(shape, results)
+}
+
+// statOp returns the unary op function for the statistic f, which is
+// computed along the first axis of a matrix.
+func statOp(f func(Context, Value) Value) unaryFn {
+	return func(c Context, v Value) Value {
+		if m, ok := v.(*Matrix); ok {
+			return alongFirstAxis(c, m, f)
+		}
+		return f(c, v)
+	}
+}
+
+// binaryStatOp returns the binary op function for the statistic f of
+// two samples. For matrices, which must have the same shape, it is
+// computed for corresponding columns.
+func binaryStatOp(op string, f func(Context, Value, Value) Value) binaryFn {
+	return func(c Context, u, v Value) Value {
+		m, mOK := u.(*Matrix)
+		n, nOK := v.(*Matrix)
+		if mOK != nOK || mOK && !sameShape(m.shape, n.shape) {
+			Errorf("%s: shape mismatch", op)
+		}
+		if !mOK {
+			return f(c, u, v)
+		}
+		ucols, shape := columns(m)
+		vcols, _ := columns(n)
+		results := make([]Value, len(ucols))
+		for j := range results {
+			results[j] = f(c, ucols[j], vcols[j])
+		}
+		if len(shape) == 1 {
+			return NewVector(results)
+		}
+		return NewMatrix(shape, results)
+	}
+}
+
+func cov(c Context, u, v Value) Value {
+	return covariance("cov", sampleOf(c, "cov", u), sampleOf(c, "cov", v))
+}
+
+func corr(c Context, u, v Value) Value {
+	s, t := sampleOf(c, "corr", u), sampleOf(c, "corr", v)
+	uv := covariance("corr", s, t)
+	uu := covariance("corr", s, s)
+	vv := covariance("corr", t, t)
+	return c.EvalBinary(uv, "/", c.EvalUnary("sqrt", c.EvalBinary(uu, "*", vv)))
+}
+
+// quantileOp returns the binary op function for quantile, or for
+// percentile if scale is 100. For a matrix, the quantiles are computed
+// for each column, and the results for each probability form a row.
+func quantileOp(op string, scale int64) binaryFn {
+	return func(c Context, p, v Value) Value {
+		if scale != 1 {
+			p = c.EvalBinary(p, "/", Int(scale))
+		}
+		m, ok := v.(*Matrix)
+		if !ok {
+			return quantiles(c, op, p, v)
+		}
+		cols, shape := columns(m)
+		n := 1
+		if p.Rank() > 0 {
+			n = len(sampleValues(p.toType(op, c.Config(), vectorType)))
+			shape = append([]int{n}, shape...)
+		}
+		results := make([]Value, n*len(cols))
+		for j, col := range cols {
+			q := quantiles(c, op, p, col)
+			for i := 0; i < n; i++ {
+				if p.Rank() > 0 {
+					results[i*len(cols)+j] = q.(Vector)[i]
+				} else {
+					results[j] = q
+				}
+			}
+		}
+		if len(shape) == 1 {
+			return NewVector(results)
+		}
+		return NewMatrix(shape, results)
+	}
+}
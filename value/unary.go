@@ -66,7 +66,7 @@ func returnZero(c Context, v Value) Value {
 
 func realPhase(c Context, v Value) Value {
 	if isNegative(v) {
-		return BigFloat{newFloat(c).Set(floatPi)}
+		return BigFloat{newFloat(c).Set(constsFor(c.Config()).pi)}
 	}
 	return Int(0)
 }
@@ -100,6 +100,8 @@ func floatSelf(c Context, v Value) BigFloat {
 		return v.toType("float", conf, bigFloatType).(BigFloat)
 	case BigFloat:
 		return v
+	case Float64:
+		return v.toType("float", conf, bigFloatType).(BigFloat)
 	}
 	Errorf("internal error: floatSelf of non-number")
 	panic("unreached")
@@ -109,13 +111,20 @@ func floatSelf(c Context, v Value) BigFloat {
 // of the value.
 func text(c Context, v Value) Value {
 	str := v.Sprint(c.Config())
-	elem := make([]Value, utf8.RuneCountInString(str))
-	for i, r := range str {
-		elem[i] = Char(r)
+	elem := make([]Value, 0, utf8.RuneCountInString(str))
+	for _, r := range str {
+		elem = append(elem, Char(r))
 	}
 	return NewVector(elem)
 }
 
+// raise implements the raise op, which fails with an error whose
+// message is the text of v, typically a string.
+func raise(c Context, v Value) Value {
+	Errorf("%s", v.Sprint(c.Config()))
+	return nil
+}
+
 // Implemented in package run, handled as a func to avoid a dependency loop.
 var IvyEval func(context Context, s string) Value
 
@@ -155,6 +164,58 @@ func init() {
 			},
 		},
 
+		{
+			name: "uniform",
+			fn: [numType]unaryFn{
+				intType: func(c Context, v Value) Value {
+					return uniform(c, 0, 1, v)
+				},
+				vectorType: func(c Context, v Value) Value {
+					return uniform(c, 0, 1, v)
+				},
+			},
+		},
+
+		{
+			name: "normal",
+			fn: [numType]unaryFn{
+				intType: func(c Context, v Value) Value {
+					return normal(c, 0, 1, v)
+				},
+				vectorType: func(c Context, v Value) Value {
+					return normal(c, 0, 1, v)
+				},
+			},
+		},
+
+		{
+			name: "expo",
+			fn: [numType]unaryFn{
+				intType: func(c Context, v Value) Value {
+					return expo(c, 1, v)
+				},
+				vectorType: func(c Context, v Value) Value {
+					return expo(c, 1, v)
+				},
+			},
+		},
+
+		{
+			name: "shuffle",
+			fn: [numType]unaryFn{
+				intType:         self,
+				charType:        self,
+				bigIntType:      self,
+				bigRatType:      self,
+				bigFloatType:    self,
+				complexType:     self,
+				boxType:         self,
+				vectorType:      shuffle,
+				arrowVectorType: shuffle,
+				matrixType:      shuffle,
+			},
+		},
+
 		{
 			name:        "j",
 			elementwise: true,
@@ -197,7 +258,7 @@ func init() {
 			elementwise: true,
 			fn: [numType]unaryFn{
 				intType: func(c Context, v Value) Value {
-					return -v.(Int)
+					return (-v.(Int)).maybeBig()
 				},
 				bigIntType: func(c Context, v Value) Value {
 					return unaryBigIntOp(c, bigIntWrap((*big.Int).Neg), v)
@@ -350,7 +411,7 @@ func init() {
 					if i < 0 {
 						i = -i
 					}
-					return i
+					return i.maybeBig()
 				},
 				bigIntType: func(c Context, v Value) Value {
 					return unaryBigIntOp(c, bigIntWrap((*big.Int).Abs), v)
@@ -507,10 +568,12 @@ func init() {
 					if i == 0 {
 						return Vector{}
 					}
-					data := constIota(c.Config().Origin(), int(i))
-					n := make([]Value, i)
-					copy(n, data)
-					return NewVector(n)
+					x := make([]int64, i)
+					origin := int64(c.Config().Origin())
+					for k := range x {
+						x[k] = origin + int64(k)
+					}
+					return newPackedInts(x)
 				},
 			},
 		},
@@ -536,6 +599,9 @@ func init() {
 				complexType: func(c Context, v Value) Value {
 					return Int(0)
 				},
+				boxType: func(c Context, v Value) Value {
+					return Int(0)
+				},
 				vectorType: func(c Context, v Value) Value {
 					return Int(len(v.(Vector)))
 				},
@@ -557,6 +623,7 @@ func init() {
 				bigRatType:   vectorSelf,
 				bigFloatType: vectorSelf,
 				complexType:  vectorSelf,
+				boxType:      vectorSelf,
 				vectorType:   self,
 				matrixType: func(c Context, v Value) Value {
 					return v.(*Matrix).data.Copy()
@@ -604,6 +671,114 @@ func init() {
 			},
 		},
 
+		{
+			name: "count",
+			fn: [numType]unaryFn{
+				intType:      countScalar,
+				charType:     countScalar,
+				bigIntType:   countScalar,
+				bigRatType:   countScalar,
+				bigFloatType: countScalar,
+				complexType:  countScalar,
+				boxType:      countScalar,
+				vectorType: func(c Context, v Value) Value {
+					return count(v.(Vector))
+				},
+				arrowVectorType: func(c Context, v Value) Value {
+					return countArrow(v.(ArrowVector))
+				},
+			},
+		},
+
+		{
+			name: "unique",
+			fn: [numType]unaryFn{
+				intType:      self,
+				charType:     self,
+				bigIntType:   self,
+				bigRatType:   self,
+				bigFloatType: self,
+				complexType:  self,
+				boxType:      self,
+				vectorType: func(c Context, v Value) Value {
+					return unique(v.(Vector))
+				},
+				arrowVectorType: func(c Context, v Value) Value {
+					return unique(v.(ArrowVector).ToVector())
+				},
+			},
+		},
+
+		{
+			name: "mean",
+			fn: [numType]unaryFn{
+				intType:         statOp(mean),
+				charType:        statOp(mean),
+				bigIntType:      statOp(mean),
+				bigRatType:      statOp(mean),
+				bigFloatType:    statOp(mean),
+				vectorType:      statOp(mean),
+				arrowVectorType: statOp(mean),
+				matrixType:      statOp(mean),
+			},
+		},
+
+		{
+			name: "median",
+			fn: [numType]unaryFn{
+				intType:         statOp(median),
+				charType:        statOp(median),
+				bigIntType:      statOp(median),
+				bigRatType:      statOp(median),
+				bigFloatType:    statOp(median),
+				vectorType:      statOp(median),
+				arrowVectorType: statOp(median),
+				matrixType:      statOp(median),
+			},
+		},
+
+		{
+			name: "mode",
+			fn: [numType]unaryFn{
+				intType:         statOp(mode),
+				charType:        statOp(mode),
+				bigIntType:      statOp(mode),
+				bigRatType:      statOp(mode),
+				bigFloatType:    statOp(mode),
+				vectorType:      statOp(mode),
+				arrowVectorType: statOp(mode),
+				matrixType:      statOp(mode),
+			},
+		},
+
+		{
+			name: "var",
+			fn: [numType]unaryFn{
+				intType:         statOp(variance),
+				charType:        statOp(variance),
+				bigIntType:      statOp(variance),
+				bigRatType:      statOp(variance),
+				bigFloatType:    statOp(variance),
+				vectorType:      statOp(variance),
+				arrowVectorType: statOp(variance),
+				matrixType:      statOp(variance),
+			},
+		},
+
+		{
+			name: "sdev",
+			fn: [numType]unaryFn{
+				intType:         statOp(sdev),
+				charType:        statOp(sdev),
+				bigIntType:      statOp(sdev),
+				bigRatType:      statOp(sdev),
+				bigFloatType:    statOp(sdev),
+				vectorType:      statOp(sdev),
+				arrowVectorType: statOp(sdev),
+				matrixType:      statOp(sdev),
+			},
+		},
+
 		{
 			name: "rot",
 			fn: [numType]unaryFn{
@@ -674,6 +849,68 @@ func init() {
 			},
 		},
 
+		{
+			name: "inv",
+			fn: [numType]unaryFn{
+				intType:         inverse,
+				bigIntType:      inverse,
+				bigRatType:      inverse,
+				bigFloatType:    inverse,
+				complexType:     inverse,
+				vectorType:      inverse,
+				arrowVectorType: inverse,
+				matrixType:      inverse,
+			},
+		},
+
+		{
+			name: "box",
+			fn: [numType]unaryFn{
+				intType:         self,
+				charType:        self,
+				bigIntType:      self,
+				bigRatType:      self,
+				bigFloatType:    self,
+				complexType:     self,
+				boxType:         func(c Context, v Value) Value { return enclose(v) },
+				vectorType:      func(c Context, v Value) Value { return enclose(v) },
+				arrowVectorType: func(c Context, v Value) Value { return enclose(v) },
+				matrixType:      func(c Context, v Value) Value { return enclose(v) },
+			},
+		},
+
+		{
+			name: "unbox",
+			fn: [numType]unaryFn{
+				intType:         self,
+				charType:        self,
+				bigIntType:      self,
+				bigRatType:      self,
+				bigFloatType:    self,
+				complexType:     self,
+				boxType:         disclose,
+				vectorType:      disclose,
+				arrowVectorType: self,
+				matrixType:      disclose,
+			},
+		},
+
+		{
+			name: "depth",
+			fn: [numType]unaryFn{
+				intType:         returnZero,
+				charType:        returnZero,
+				bigIntType:      returnZero,
+				bigRatType:      returnZero,
+				bigFloatType:    returnZero,
+				complexType:     returnZero,
+				boxType:         func(c Context, v Value) Value { return Int(depth(v)) },
+				vectorType:      func(c Context, v Value) Value { return Int(depth(v)) },
+				arrowVectorType: func(c Context, v Value) Value { return Int(depth(v)) },
+				matrixType:      func(c Context, v Value) Value { return Int(depth(v)) },
+			},
+		},
+
 		{
 			name: "transp",
 			fn: [numType]unaryFn{
@@ -683,6 +920,7 @@ func init() {
 				bigRatType:   self,
 				bigFloatType: self,
 				complexType:  self,
+				boxType:      self,
 				vectorType: func(c Context, v Value) Value {
 					return v.(Vector).Copy()
 				},
@@ -900,6 +1138,7 @@ func init() {
 				bigRatType:   func(c Context, v Value) Value { return text(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return text(c, v) },
 				complexType:  func(c Context, v Value) Value { return text(c, v) },
+				boxType:      func(c Context, v Value) Value { return text(c, v) },
 				vectorType:   func(c Context, v Value) Value { return text(c, v) },
 				matrixType:   func(c Context, v Value) Value { return text(c, v) },
 			},
@@ -922,6 +1161,45 @@ func init() {
 			},
 		},
 
+		{
+			name: "raise",
+			fn: [numType]unaryFn{
+				intType:         raise,
+				charType:        raise,
+				bigIntType:      raise,
+				bigRatType:      raise,
+				bigFloatType:    raise,
+				complexType:     raise,
+				boxType:         raise,
+				vectorType:      raise,
+				arrowVectorType: raise,
+				matrixType:      raise,
+			},
+		},
+
+		{
+			name: "json",
+			fn: [numType]unaryFn{
+				intType:         jsonText,
+				charType:        jsonText,
+				bigIntType:      jsonText,
+				bigRatType:      jsonText,
+				bigFloatType:    jsonText,
+				complexType:     jsonText,
+				vectorType:      jsonText,
+				arrowVectorType: jsonText,
+				matrixType:      jsonText,
+			},
+		},
+
+		{
+			name: "fromjson",
+			fn: [numType]unaryFn{
+				charType:   fromJSON,
+				vectorType: fromJSON,
+			},
+		},
+
 		{
 			name:        "float",
 			elementwise: true,
@@ -939,6 +1217,7 @@ func init() {
 	}
 
 	for _, op := range ops {
+		op.fn[float64Type] = float64Unary(op.name, op.fn[bigFloatType])
 		UnaryOps[op.name] = op
 	}
 }
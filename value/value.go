@@ -145,11 +145,16 @@ func bigRatTwoInt64s(x, y int64) BigRat {
 	return BigRat{big.NewRat(x, y)}
 }
 
+// ToArrowColumn returns the value as an Arrow column, or nil if it
+// cannot be converted. The caller must release the column.
 func ToArrowColumn(value Value, mem memory.Allocator) *arrow.Column {
 	switch v := value.(type) {
 	case Vector:
 		return v.ToArrowCol(mem)
+	case PackedVector:
+		return v.ToVector().ToArrowCol(mem)
 	case ArrowVector:
+		v.col.Retain()
 		return v.col
 	case Int:
 		return IntToArrowIntCol(v, mem)
@@ -158,6 +163,8 @@ func ToArrowColumn(value Value, mem memory.Allocator) *arrow.Column {
 		return BigIntToArrowFloatCol(v, mem)
 	case BigFloat:
 		return FloatToArrowFloatCol(v, mem)
+	case Float64:
+		return FloatToArrowFloatCol(BigFloat{new(big.Float).SetFloat64(float64(v))}, mem)
 	case *Matrix:
 		x := v.Data()
 		return x.ToArrowCol(mem)
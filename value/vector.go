@@ -7,7 +7,6 @@ package value
 import (
 	"bytes"
 	"fmt"
-	"math"
 	"runtime"
 	"sort"
 
@@ -50,6 +49,9 @@ func (v Vector) ProgString() string {
 // default (that is, by calling String) spaces are suppressed
 // if all the elements of the Vector are Chars.
 func (v Vector) makeString(conf *config.Config, spaces bool) string {
+	if hasBoxes(v) {
+		return boxedGrid(conf, v, 1, len(v))
+	}
 	var b bytes.Buffer
 	for i, elem := range v {
 		if spaces && i > 0 {
@@ -110,6 +112,11 @@ func (v Vector) toType(op string, conf *config.Config, which valueType) Value {
 	switch which {
 	case vectorType:
 		return v
+	case packedVectorType:
+		if p, ok := pack(v); ok {
+			return p
+		}
+		return v
 	case arrowVectorType:
 		return v
 	case matrixType:
@@ -149,6 +156,9 @@ func doRotate(dst, src []Value, j int) {
 
 // grade returns as a Vector the indexes that sort the vector into increasing order
 func (v Vector) grade(c Context) Vector {
+	if hasBoxes(v) {
+		Errorf("cannot grade boxes")
+	}
 	x := make([]int, len(v))
 	for i := range x {
 		x[i] = i
@@ -174,38 +184,18 @@ func (v Vector) reverse() Vector {
 
 // membership creates a vector of size len(u) reporting
 // whether each element is an element of v.
-// Algorithm is O(nV log nV + nU log nV) where nU==len(u) and nV==len(V).
+// Algorithm is O(nU + nV) where nU==len(u) and nV==len(V).
 func membership(c Context, u, v Vector) []Value {
+	// Find the elements by their set keys, so that in agrees with
+	// the set operations, such as intersect, and with unique.
 	values := make([]Value, len(u))
-	sortedV := v.sortedCopy(c)
-	work := 2 * (1 + int(math.Log2(float64(len(v)))))
-	pfor(true, work, len(values), func(lo, hi int) {
-		for i := lo; i < hi; i++ {
-			values[i] = toInt(sortedV.contains(c, u[i]))
-		}
-	})
+	set := keySet(v)
+	for i, x := range u {
+		values[i] = toInt(set[keyOf(x)])
+	}
 	return values
 }
 
-// sortedCopy returns a copy of v, in ascending sorted order.
-func (v Vector) sortedCopy(c Context) Vector {
-	sortedV := make([]Value, len(v))
-	copy(sortedV, v)
-	sort.Slice(sortedV, func(i, j int) bool {
-		return c.EvalBinary(sortedV[i], "<", sortedV[j]) == Int(1)
-	})
-	return sortedV
-}
-
-// contains reports whether x is in v, which must be already in ascending
-// sorted order.
-func (v Vector) contains(c Context, x Value) bool {
-	pos := sort.Search(len(v), func(j int) bool {
-		return c.EvalBinary(v[j], ">=", x) == Int(1)
-	})
-	return pos < len(v) && c.EvalBinary(v[pos], "==", x) == Int(1)
-}
-
 func (v Vector) shrink() Value {
 	if len(v) == 1 {
 		return v[0]
@@ -216,7 +206,7 @@ func (v Vector) shrink() Value {
 func (v Vector) ToArrowCol(mem memory.Allocator) *arrow.Column {
 	for _, i := range v {
 		switch i.(type) {
-		case BigFloat:
+		case BigFloat, Float64:
 			return ToArrowFloatCol(v, mem)
 		case Int:
 		default:
@@ -251,7 +241,9 @@ func ToArrowIntCol(v Vector, mem memory.Allocator) *arrow.Column {
 
 	table := array.NewTableFromRecords(schema, []arrow.Record{b.NewRecord()})
 	defer table.Release()
-	return table.Column(0)
+	col := table.Column(0)
+	col.Retain() // Keep the column alive after the table is released.
+	return col
 }
 
 func ToArrowFloatCol(v Vector, mem memory.Allocator) *arrow.Column {
@@ -270,6 +262,8 @@ func ToArrowFloatCol(v Vector, mem memory.Allocator) *arrow.Column {
 		switch val := v[i].(type) {
 		case BigFloat:
 			vals[i], _ = val.Float64()
+		case Float64:
+			vals[i] = float64(val)
 		case Int:
 			vals[i] = float64(val)
 		}
@@ -277,5 +271,7 @@ func ToArrowFloatCol(v Vector, mem memory.Allocator) *arrow.Column {
 	b.Field(0).(*array.Float64Builder).AppendValues(vals, nil)
 	table := array.NewTableFromRecords(schema, []arrow.Record{b.NewRecord()})
 	defer table.Release()
-	return table.Column(0)
+	col := table.Column(0)
+	col.Retain() // Keep the column alive after the table is released.
+	return col
 }
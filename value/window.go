@@ -0,0 +1,253 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "math"
+
+// inverseOps maps the binary ops whose effect can be undone to the op
+// that undoes it, so a window reduction can slide in O(n) time. Only
+// exact arithmetic can be undone: a float sum can lose the small terms
+// of a window to a large one that is then taken away again.
+var inverseOps = map[string]string{
+	"+": "-",
+	"^": "^",
+}
+
+// windowSize returns the window size given by u for windows of a
+// vector of length n, and whether the windows are to be reversed.
+func windowSize(op string, u Value, n int) (int, bool) {
+	i, ok := u.(Int)
+	if !ok {
+		Errorf("%s: window size must be a small integer", op)
+	}
+	reverse := i < 0
+	if reverse {
+		i = -i
+	}
+	if i == 0 || int64(i) > int64(n)+1 {
+		Errorf("%s: invalid window size %d for length %d", op, u.(Int), n)
+	}
+	return int(i), reverse
+}
+
+// NwiseReduce computes an n-wise reduction such as 3 +/ v, the
+// reduction by op of each window of n consecutive elements of v. If
+// n is negative, the elements of each window are reversed. For a
+// matrix, the windows lie along the last axis. The slash has been
+// removed.
+func NwiseReduce(c Context, u Value, op string, v Value) Value {
+	switch v := v.(type) {
+	case Vector:
+		n, reverse := windowSize(op+"/", u, len(v))
+		return NewVector(windows(c, n, reverse, op, v))
+	case ArrowVector:
+		n, reverse := windowSize(op+"/", u, v.Len())
+		if !reverse {
+			if w := intWindows(v, n, op); w != nil {
+				return NewVector(w)
+			}
+		}
+		return NewVector(windows(c, n, reverse, op, v.ToVector()))
+	case PackedVector:
+		n, reverse := windowSize(op+"/", u, v.Len())
+		if !reverse {
+			if w := intWindows(v, n, op); w != nil {
+				return NewVector(w)
+			}
+		}
+		return NewVector(windows(c, n, reverse, op, v.ToVector()))
+	case *Matrix:
+		if v.Rank() < 2 {
+			Errorf("shape for matrix is degenerate: %s", NewIntVector(v.shape))
+		}
+		stride := v.shape[v.Rank()-1]
+		n, reverse := windowSize(op+"/", u, stride)
+		shape := append(v.shape[:v.Rank()-1:v.Rank()-1], stride-n+1)
+		var data Vector
+		for i := 0; i < len(v.data); i += stride {
+			data = append(data, windows(c, n, reverse, op, v.data[i:i+stride])...)
+		}
+		return NewMatrix(shape, data)
+	}
+	return NwiseReduce(c, u, op, NewVector([]Value{v}))
+}
+
+// windows returns the reductions by op of the windows of size n in v.
+func windows(c Context, n int, reverse bool, op string, v Vector) Vector {
+	m := len(v) - n + 1
+	if reverse {
+		// The reversed windows of v are the windows of
+		// reversed v, in the opposite order.
+		r := make(Vector, len(v))
+		for i, x := range v {
+			r[len(v)-1-i] = x
+		}
+		w := windows(c, n, false, op, r)
+		for i, j := 0, m-1; i < j; i, j = i+1, j-1 {
+			w[i], w[j] = w[j], w[i]
+		}
+		return w
+	}
+	result := make(Vector, m)
+	if m == 0 {
+		return result
+	}
+	if inv, ok := inverseOps[op]; ok && allExact(v) {
+		// Slide the window, removing the element that leaves
+		// and adding the one that arrives.
+		acc := Reduce(c, op, v[:n])
+		result[0] = acc
+		for i := 1; i < m; i++ {
+			acc = c.EvalBinary(c.EvalBinary(acc, inv, v[i-1]), op, v[i+n-1])
+			result[i] = acc
+		}
+		return result
+	}
+	if op == "max" || op == "min" {
+		return slidingExtreme(c, n, op, v)
+	}
+	pfor(safeBinary(op), n, m, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			result[i] = enclose(Reduce(c, op, v[i:i+n]))
+		}
+	})
+	return result
+}
+
+// allExact reports whether the elements of v are exact: integers,
+// rationals, chars, or complex numbers with exact parts.
+func allExact(v Vector) bool {
+	for _, x := range v {
+		switch x := x.(type) {
+		case Int, BigInt, BigRat, Char:
+		case Complex:
+			if !allExact(Vector{x.real, x.imag}) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// slidingExtreme returns the maximum or minimum of each window of size
+// n in v. It keeps the indexes of the candidates for the extreme in a
+// queue, so each element is examined a constant number of times.
+func slidingExtreme(c Context, n int, op string, v Vector) Vector {
+	cmp := ">="
+	if op == "min" {
+		cmp = "<="
+	}
+	result := make(Vector, len(v)-n+1)
+	var queue []int
+	for i, x := range v {
+		for len(queue) > 0 && isTrue(op, c.EvalBinary(x, cmp, v[queue[len(queue)-1]])) {
+			queue = queue[:len(queue)-1]
+		}
+		queue = append(queue, i)
+		if queue[0] <= i-n {
+			queue = queue[1:]
+		}
+		if i >= n-1 {
+			result[i-n+1] = v[queue[0]]
+		}
+	}
+	return result
+}
+
+// int64Vector is an Arrow column or packed vector whose integer
+// elements can be read directly.
+type int64Vector interface {
+	int64s() ([]int64, bool)
+}
+
+// intWindows returns the window sums, maxima or minima for an
+// integer column or packed vector, reading it directly. It returns
+// nil if it cannot; in particular if a sum overflows.
+func intWindows(v int64Vector, n int, op string) Vector {
+	x, ok := v.int64s()
+	if !ok {
+		return nil
+	}
+	result := make(Vector, len(x)-n+1)
+	if len(result) == 0 {
+		return result
+	}
+	switch op {
+	case "+":
+		var acc int64
+		for i, e := range x {
+			var overflow bool
+			if acc, overflow = addInt64(acc, e); overflow {
+				return nil
+			}
+			if i >= n {
+				if x[i-n] == math.MinInt64 {
+					return nil
+				}
+				if acc, overflow = addInt64(acc, -x[i-n]); overflow {
+					return nil
+				}
+			}
+			if i >= n-1 {
+				result[i-n+1] = fromGoInt(acc)
+			}
+		}
+	case "max", "min":
+		var queue []int
+		for i, e := range x {
+			for len(queue) > 0 && (op == "max" && e >= x[queue[len(queue)-1]] || op == "min" && e <= x[queue[len(queue)-1]]) {
+				queue = queue[:len(queue)-1]
+			}
+			queue = append(queue, i)
+			if queue[0] <= i-n {
+				queue = queue[1:]
+			}
+			if i >= n-1 {
+				result[i-n+1] = fromGoInt(x[queue[0]])
+			}
+		}
+	default:
+		return nil
+	}
+	return result
+}
+
+// addInt64 returns a+b and whether the sum overflows.
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (a >= 0) == (b >= 0) && (sum >= 0) != (a >= 0)
+}
+
+// window returns the matrix whose columns are the windows of size u
+// of the vector v, so statistics of the matrix, which are computed
+// along its first axis, are rolling statistics of v.
+func window(c Context, u, v Value) Value {
+	var data Vector
+	switch v := v.(type) {
+	case Vector:
+		data = v
+	case PackedVector:
+		data = v.ToVector()
+	case ArrowVector:
+		data = v.ToVector()
+	default:
+		Errorf("window: argument must be a vector")
+	}
+	n, reverse := windowSize("window", u, len(data))
+	m := len(data) - n + 1
+	result := make(Vector, n*m)
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			k := i
+			if reverse {
+				k = n - 1 - i
+			}
+			result[i*m+j] = data[j+k]
+		}
+	}
+	return NewMatrix([]int{n, m}, result)
+}